@@ -0,0 +1,137 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+	"github.com/campoy/podcast-to-youtube/youtube"
+)
+
+// captionTracks returns the transcripts of an episode that can be uploaded
+// as captions, at most one per language, with their language set. Transcripts
+// without a language are in the audio language of the video. For every
+// language, timed captions are preferred over other transcripts, and SubRip
+// or WebVTT documents over JSON ones.
+func captionTracks(s show, ep podcast.Episode) []podcast.Transcript {
+	var tracks []podcast.Transcript
+	index := map[string]int{}
+	for _, t := range ep.Transcripts {
+		if captionRank(t) < 0 {
+			continue
+		}
+		if t.Language == "" {
+			t.Language = episodeMetadata(s, ep).DefaultAudioLanguage
+		} else {
+			t.Language = languageTag(t.Language)
+		}
+		if t.Language == "" {
+			continue
+		}
+		i, ok := index[t.Language]
+		if !ok {
+			index[t.Language] = len(tracks)
+			tracks = append(tracks, t)
+		} else if captionRank(t) > captionRank(tracks[i]) {
+			tracks[i] = t
+		}
+	}
+	return tracks
+}
+
+// captionRank ranks a transcript by how good a caption track it makes, or
+// returns -1 if its format is not supported.
+func captionRank(t podcast.Transcript) int {
+	rank := 0
+	switch t.Type {
+	case "application/x-subrip", "application/srt", "text/srt", "text/vtt":
+		rank = 1
+	case "application/json":
+	default:
+		return -1
+	}
+	if t.Rel == "captions" {
+		rank += 2
+	}
+	return rank
+}
+
+// A captionError reports the caption tracks that could not be added to the
+// video of an episode, which was published nonetheless.
+type captionError struct {
+	video string
+	errs  []error
+}
+
+func (e *captionError) Error() string {
+	msgs := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("published video %s without some captions: %s", e.video, strings.Join(msgs, "; "))
+}
+
+// addCaptions fetches the transcripts of an episode and uploads them as
+// captions of the given video, converted to SubRip. It returns the number of
+// tracks uploaded and, if any failed, a *captionError. Since every upload
+// would fail the same way, it stops if the token lacks the permission.
+func addCaptions(client *youtube.Client, fetcher *podcast.Fetcher, s show, ep podcast.Episode, video string) (int, error) {
+	tracks := captionTracks(s, ep)
+	if len(tracks) == 0 {
+		return 0, nil
+	}
+	tmp, err := ioutil.TempDir("", "")
+	if err != nil {
+		return 0, &captionError{video, []error{fmt.Errorf("could not create temp directory: %v", err)}}
+	}
+	defer os.RemoveAll(tmp)
+
+	n := 0
+	var errs []error
+	for i, t := range tracks {
+		if err := fetcher.FetchTranscript(context.Background(), &t); err != nil {
+			errs = append(errs, fmt.Errorf("could not fetch transcript %s: %v", t.URL, err))
+			continue
+		}
+		if len(t.Cues) == 0 {
+			log.Printf("skipping empty transcript %s", t.URL)
+			continue
+		}
+		path := filepath.Join(tmp, fmt.Sprintf("%d.srt", i))
+		if err := ioutil.WriteFile(path, podcast.FormatSRT(t.Cues), 0644); err != nil {
+			errs = append(errs, fmt.Errorf("could not write captions: %v", err))
+			continue
+		}
+		if err := client.UploadCaption(video, t.Language, "", path); err != nil {
+			errs = append(errs, err)
+			if errors.Is(err, youtube.ErrMissingScope) {
+				break
+			}
+			continue
+		}
+		n++
+	}
+	if len(errs) > 0 {
+		return n, &captionError{video, errs}
+	}
+	return n, nil
+}
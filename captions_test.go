@@ -0,0 +1,49 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+)
+
+func TestCaptionTracks(t *testing.T) {
+	ep := podcast.Episode{
+		Podcast: &podcast.Podcast{Language: "en-us"},
+		Transcripts: []podcast.Transcript{
+			{URL: "a.json", Type: "application/json"},
+			{URL: "a.html", Type: "text/html", Language: "fr"},
+			{URL: "a.vtt", Type: "text/vtt", Language: "en-US"},
+			{URL: "b.json", Type: "application/json", Language: "es"},
+			{URL: "b.srt", Type: "application/x-subrip", Language: "es", Rel: "captions"},
+			{URL: "c.vtt", Type: "text/vtt", Language: "es"},
+		},
+	}
+	tracks := captionTracks(show{}, ep)
+	var got []string
+	for _, t := range tracks {
+		got = append(got, t.Language+" "+t.URL)
+	}
+	want := []string{"en-US a.vtt", "es b.srt"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("expected tracks %q; got %q", want, got)
+	}
+
+	ep.Podcast = nil
+	ep.Transcripts = []podcast.Transcript{{URL: "a.srt", Type: "text/srt"}}
+	if tracks := captionTracks(show{}, ep); len(tracks) != 0 {
+		t.Errorf("expected no tracks without a language; got %+v", tracks)
+	}
+}
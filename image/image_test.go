@@ -14,13 +14,21 @@
 package image
 
 import (
+	"bytes"
 	"image"
 	"image/color"
+	"image/jpeg"
 	"image/png"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
+	"strings"
 	"testing"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
 )
 
 var goldenImage = func() image.Image {
@@ -82,3 +90,77 @@ func checkImagesEq(t *testing.T, a, b image.Image) {
 	t.Errorf("see differences as red pixels on diff.png")
 	exec.Command("open", "diff.png").Run()
 }
+
+func TestThumbnail(t *testing.T) {
+	p := ThumbnailParams{
+		Artwork:    "../resources/logo.png",
+		Number:     42,
+		Title:      "A rather long title for an episode that will not fit in the three lines we allow for it in the thumbnail",
+		Font:       "../resources/Roboto-Light.ttf",
+		Foreground: color.White,
+		Background: color.RGBA{50, 100, 50, 255},
+		Width:      1280,
+		Height:     720,
+	}
+	m, err := Thumbnail(p)
+	if err != nil {
+		t.Fatalf("could not generate thumbnail: %v", err)
+	}
+	if b := m.Bounds(); b.Dx() != p.Width || b.Dy() != p.Height {
+		t.Errorf("expected a %dx%d thumbnail; got %v", p.Width, p.Height, b)
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeThumbnail(&buf, m, MaxThumbnailSize); err != nil {
+		t.Fatalf("could not encode thumbnail: %v", err)
+	}
+	if buf.Len() > MaxThumbnailSize {
+		t.Errorf("thumbnail takes %d bytes; expected at most %d", buf.Len(), MaxThumbnailSize)
+	}
+	if _, err := jpeg.Decode(&buf); err != nil {
+		t.Errorf("could not decode thumbnail: %v", err)
+	}
+	if err := EncodeThumbnail(ioutil.Discard, m, 100); err == nil {
+		t.Errorf("expected error encoding thumbnail in 100 bytes")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	f, err := loadFont("../resources/Roboto-Light.ttf")
+	if err != nil {
+		t.Fatalf("could not load font: %v", err)
+	}
+	face := truetype.NewFace(f, &truetype.Options{Size: 20, Hinting: font.HintingNone, DPI: 72})
+	width := fixed.I(200)
+
+	tests := []struct {
+		name     string
+		text     string
+		lines    int
+		ellipsis bool
+	}{
+		{"short", "Hello, gophers", 1, false},
+		{"wrapped", "A title that needs a couple of lines to fit", 2, false},
+		{"long word", "Supercalifragilisticexpialidocious and friends", 2, false},
+		{"too long", strings.Repeat("word ", 40), 3, true},
+		{"too long word", strings.Repeat("x", 200), 3, true},
+	}
+	for _, tt := range tests {
+		lines := wrap(face, tt.text, width, 3)
+		if len(lines) != tt.lines {
+			t.Errorf("%s: expected %d lines; got %q", tt.name, tt.lines, lines)
+			continue
+		}
+		for _, l := range lines {
+			if w := font.MeasureString(face, l); w > width {
+				t.Errorf("%s: line %q is %v wide; expected at most %v", tt.name, l, w, width)
+			}
+		}
+		if got := strings.HasSuffix(lines[len(lines)-1], "…"); got != tt.ellipsis {
+			t.Errorf("%s: expected ellipsis %v; got %q", tt.name, tt.ellipsis, lines)
+		}
+		if !tt.ellipsis && strings.Join(strings.Fields(strings.Join(lines, "")), "") != strings.Join(strings.Fields(tt.text), "") {
+			t.Errorf("%s: lines %q do not contain the whole text", tt.name, lines)
+		}
+	}
+}
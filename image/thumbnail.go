@@ -0,0 +1,177 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"strings"
+
+	"github.com/golang/freetype/truetype"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// MaxThumbnailSize is the maximum size in bytes of a YouTube thumbnail.
+const MaxThumbnailSize = 2 << 20
+
+// maxTitleLines is the number of lines the title of a thumbnail can take.
+const maxTitleLines = 3
+
+// ThumbnailParams contains the parameters that describe a thumbnail.
+// Artwork and Number are optional, the others are required.
+type ThumbnailParams struct {
+	Artwork    string      // Filepath to the episode artwork, drawn on the left.
+	Number     int         // Episode number, drawn large above the title.
+	Title      string      // Title of the episode, wrapped and shortened to fit.
+	Font       string      // Filepath to the TrueType font used for the text.
+	Foreground color.Color // Color for the text.
+	Background color.Color // Color for the background.
+	Width      int         // Width of the image in pixels.
+	Height     int         // Height of the image in pixels.
+}
+
+// Thumbnail generates a new thumbnail given the corresponding parameters.
+func Thumbnail(p ThumbnailParams) (image.Image, error) {
+	m := image.NewRGBA(image.Rect(0, 0, p.Width, p.Height))
+	draw.Draw(m, m.Bounds(), image.NewUniform(p.Background), image.Point{}, draw.Src)
+
+	// The artwork fills a square on the left, and the text the rest.
+	pad := p.Height / 20
+	left := pad
+	if p.Artwork != "" {
+		art, err := loadImg(p.Artwork)
+		if err != nil {
+			return nil, fmt.Errorf("could not open %s: %v", p.Artwork, err)
+		}
+		side := p.Height - 2*pad
+		box := fit(art.Bounds(), side).Add(image.Point{pad, pad})
+		xdraw.CatmullRom.Scale(m, box, art, art.Bounds(), xdraw.Over, nil)
+		left = 2*pad + side
+	}
+	width := fixed.I(p.Width - left - pad)
+
+	f, err := loadFont(p.Font)
+	if err != nil {
+		return nil, fmt.Errorf("could not load font: %v", err)
+	}
+
+	d := &font.Drawer{Dst: m, Src: image.NewUniform(p.Foreground)}
+	y := fixed.I(pad)
+	if p.Number != 0 {
+		text := fmt.Sprintf("#%d", p.Number)
+		d.Face = fitFace(f, float64(p.Height)/3, width, text)
+		y += d.Face.Metrics().Ascent
+		d.Dot = fixed.Point26_6{X: fixed.I(left), Y: y}
+		d.DrawString(text)
+		y += d.Face.Metrics().Descent + fixed.I(pad)
+	}
+
+	d.Face = truetype.NewFace(f, &truetype.Options{Size: float64(p.Height) / 10, Hinting: font.HintingNone, DPI: 72})
+	for _, line := range wrap(d.Face, p.Title, width, maxTitleLines) {
+		y += d.Face.Metrics().Ascent
+		d.Dot = fixed.Point26_6{X: fixed.I(left), Y: y}
+		d.DrawString(line)
+		y += d.Face.Metrics().Descent
+	}
+	return m, nil
+}
+
+// fit returns the rectangle at the origin where an image with the given
+// bounds fits in a square of the given side, keeping its aspect ratio and
+// centered in the square.
+func fit(b image.Rectangle, side int) image.Rectangle {
+	w, h := side, side
+	if b.Dx() > b.Dy() {
+		h = side * b.Dy() / b.Dx()
+	} else if b.Dy() > b.Dx() {
+		w = side * b.Dx() / b.Dy()
+	}
+	x, y := (side-w)/2, (side-h)/2
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// fitFace returns the face of the largest size, up to the given one, at which
+// text fits in the given width.
+func fitFace(f *truetype.Font, size float64, width fixed.Int26_6, text string) font.Face {
+	for ; ; size-- {
+		face := truetype.NewFace(f, &truetype.Options{Size: size, Hinting: font.HintingNone, DPI: 72})
+		if size <= 1 || font.MeasureString(face, text) <= width {
+			return face
+		}
+	}
+}
+
+// wrap splits text in at most max lines fitting in the given width. Words
+// too wide for a line are broken where they reach its end. If the text does
+// not fit, the last line is shortened and ends in an ellipsis.
+func wrap(face font.Face, text string, width fixed.Int26_6, max int) []string {
+	var lines []string
+	words := strings.Fields(text)
+	for len(words) > 0 && len(lines) < max {
+		if font.MeasureString(face, words[0]) > width {
+			head := fitPrefix(face, words[0], "", width)
+			lines = append(lines, head)
+			words[0] = words[0][len(head):]
+			continue
+		}
+		n := 1
+		for n < len(words) && font.MeasureString(face, strings.Join(words[:n+1], " ")) <= width {
+			n++
+		}
+		lines = append(lines, strings.Join(words[:n], " "))
+		words = words[n:]
+	}
+	if len(words) == 0 {
+		return lines
+	}
+
+	// The last line takes an ellipsis if words are left.
+	last := lines[len(lines)-1]
+	lines[len(lines)-1] = strings.TrimSpace(fitPrefix(face, last, "…", width)) + "…"
+	return lines
+}
+
+// fitPrefix returns the longest prefix of text that fits in the given width
+// when followed by suffix, and at least its first character.
+func fitPrefix(face font.Face, text, suffix string, width fixed.Int26_6) string {
+	runes := []rune(text)
+	for len(runes) > 1 && font.MeasureString(face, string(runes)+suffix) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes)
+}
+
+// EncodeThumbnail encodes the given image as a JPEG of at most max bytes,
+// with the highest quality that allows it.
+func EncodeThumbnail(w io.Writer, m image.Image, max int) error {
+	var buf bytes.Buffer
+	for q := 95; q > 0; q -= 10 {
+		buf.Reset()
+		if err := jpeg.Encode(&buf, m, &jpeg.Options{Quality: q}); err != nil {
+			return fmt.Errorf("could not encode thumbnail: %v", err)
+		}
+		if buf.Len() <= max {
+			_, err := buf.WriteTo(w)
+			return err
+		}
+	}
+	return fmt.Errorf("could not encode thumbnail in %d bytes", max)
+}
@@ -0,0 +1,256 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"unicode/utf8"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+)
+
+// Limits enforced by YouTube on the metadata of a video, in characters.
+const (
+	maxTitleLength       = 100
+	maxDescriptionLength = 5000
+)
+
+// Exit codes of the lint command.
+const (
+	lintOK       = 0 // No errors found.
+	lintProblems = 1 // Errors, or warnings with -strict, found.
+	lintFailed   = 2 // A feed could not be fetched or the arguments are invalid.
+)
+
+// A problem is an issue found by the lint command in an episode of a feed.
+type problem struct {
+	Feed     string `json:"feed"`
+	Episode  string `json:"episode,omitempty"` // ID of the episode.
+	Title    string `json:"title,omitempty"`
+	Check    string `json:"check"`
+	Severity string `json:"severity"` // "error" or "warning".
+	Message  string `json:"message"`
+}
+
+func (p problem) String() string {
+	ep := p.Feed
+	if p.Title != "" {
+		ep += fmt.Sprintf(": %q", p.Title)
+	} else if p.Episode != "" {
+		ep += ": " + p.Episode
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", ep, p.Severity, p.Message, p.Check)
+}
+
+// runLint runs the lint command with the given arguments over the feeds of
+// the given shows, and returns the exit code of the command.
+func runLint(fetcher *podcast.Fetcher, shows []show, args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	jsonOut := fs.Bool("json", false, "Print the problems found as a JSON array")
+	head := fs.Bool("head", true, "Check that the media of every episode can be downloaded with a HEAD request")
+	strict := fs.Bool("strict", false, "Exit with a non zero status on warnings too")
+	if err := fs.Parse(args); err != nil {
+		return lintFailed
+	}
+
+	var check func(ctx context.Context, url string) error
+	if *head {
+		client := &http.Client{Timeout: *rssTimeout}
+		check = func(ctx context.Context, url string) error { return checkMedia(ctx, client, url) }
+	}
+
+	code := lintOK
+	problems := []problem{}
+	for _, s := range shows {
+		ps, err := lint(context.Background(), fetcher, s, check)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", s.feed, err)
+			code = lintFailed
+			continue
+		}
+		problems = append(problems, ps...)
+	}
+
+	for _, p := range problems {
+		if code == lintOK && (p.Severity == "error" || *strict) {
+			code = lintProblems
+		}
+		if !*jsonOut {
+			fmt.Println(p)
+		}
+	}
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(problems); err != nil {
+			fmt.Fprintf(os.Stderr, "could not encode problems: %v\n", err)
+			return lintFailed
+		}
+	}
+	return code
+}
+
+// lint fetches the feed of the given show and returns the problems that would
+// prevent or spoil the publication of its episodes. The media of each episode
+// is checked with check, unless it is nil. Items that can not be decoded are
+// reported as problems, instead of failing, regardless of fetcher.Lenient.
+func lint(ctx context.Context, fetcher *podcast.Fetcher, s show, check func(ctx context.Context, url string) error) ([]problem, error) {
+	var problems []problem
+	add := func(ep *podcast.Episode, check, severity, format string, args ...interface{}) {
+		p := problem{Feed: s.feed, Check: check, Severity: severity, Message: fmt.Sprintf(format, args...)}
+		if ep != nil {
+			p.Episode, p.Title = ep.ID, ep.Title
+		}
+		problems = append(problems, p)
+	}
+
+	f := *fetcher
+	f.Lenient = true
+	f.Report = func(d podcast.Diagnostic) {
+		if errors.Is(d.Err, podcast.ErrCache) {
+			log.Print(d)
+			return
+		}
+		ep := &podcast.Episode{Title: d.Title}
+		if errors.Is(d.Err, podcast.ErrDuplicateID) {
+			add(ep, "duplicate-guid", "error", "%v; only the first episode with this ID is published", d.Err)
+			return
+		}
+		if errors.Is(d.Err, podcast.ErrInvalidDate) {
+			add(ep, "invalid-date", "warning", "%v", d.Err)
+			return
+		}
+		add(ep, "decode", "error", "item %d at line %d, column %d can not be decoded: %v", d.Item, d.Line, d.Column, d.Err)
+	}
+	eps, err := f.Fetch(ctx, s.feed)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct{ season, number int }
+	numbers := make(map[key]string)
+	for i := range eps {
+		ep := &eps[i]
+		if len(ep.Enclosures) == 0 {
+			add(ep, "missing-enclosure", "error", "episode has no enclosure")
+		} else if ep.Media == "" {
+			add(ep, "missing-enclosure", "error", "none of the %d enclosures has an accepted media type", len(ep.Enclosures))
+		}
+
+		// Numbers inferred from the publication order are reported as missing
+		// only, since they depend on the numbers of the other episodes.
+		k := key{ep.Season, ep.Number}
+		if ep.NumberSource == podcast.NumberFromDate {
+			add(ep, "missing-number", "warning", "episode has no number; using %d from its publication order", ep.Number)
+		} else if title, ok := numbers[k]; ok {
+			add(ep, "duplicate-number", "error", "episode number %d is also used by %q", ep.Number, title)
+		} else {
+			numbers[k] = ep.Title
+		}
+
+		title, err := s.videoTitle(*ep)
+		if err != nil {
+			add(ep, "title", "error", "%v", err)
+		} else if n := utf8.RuneCountInString(title); n > maxTitleLength {
+			add(ep, "title-too-long", "error", "video title has %d characters; YouTube accepts up to %d", n, maxTitleLength)
+		}
+		if n := utf8.RuneCountInString(videoDescription(*ep)); n > maxDescriptionLength {
+			add(ep, "description-too-long", "error", "video description has %d characters; YouTube accepts up to %d", n, maxDescriptionLength)
+		}
+	}
+
+	if check != nil {
+		problems = append(problems, checkAll(ctx, s.feed, eps, check)...)
+	}
+	return problems, nil
+}
+
+// checkAll checks the media of the given episodes concurrently, and returns
+// the problems found in the order of the episodes.
+func checkAll(ctx context.Context, feed string, eps []podcast.Episode, check func(ctx context.Context, url string) error) []problem {
+	const concurrency = 8
+	errs := make([]error, len(eps))
+	sem := make(chan bool, concurrency)
+	done := make(chan bool, len(eps))
+	running := 0
+	for i, ep := range eps {
+		if ep.Media == "" {
+			continue
+		}
+		running++
+		sem <- true
+		go func(i int, url string) {
+			errs[i] = check(ctx, url)
+			<-sem
+			done <- true
+		}(i, ep.Media)
+	}
+	for ; running > 0; running-- {
+		<-done
+	}
+
+	var problems []problem
+	for i, err := range errs {
+		if err != nil {
+			problems = append(problems, problem{
+				Feed:     feed,
+				Episode:  eps[i].ID,
+				Title:    eps[i].Title,
+				Check:    "enclosure-status",
+				Severity: "error",
+				Message:  fmt.Sprintf("could not fetch %s: %v", eps[i].Media, err),
+			})
+		}
+	}
+	return problems
+}
+
+// checkMedia checks that the media at the given URL can be downloaded. Servers
+// that do not support HEAD requests are asked for the first byte instead.
+func checkMedia(ctx context.Context, client *http.Client, url string) error {
+	status, err := request(ctx, client, "HEAD", url)
+	if status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented {
+		status, err = request(ctx, client, "GET", url)
+	}
+	if err != nil {
+		return err
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("server returned %d %s", status, http.StatusText(status))
+	}
+	return nil
+}
+
+// request sends a request with the given method to the given URL, asking for
+// its first byte only, and returns the status code of the response.
+func request(ctx context.Context, client *http.Client, method, url string) (int, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	return res.StatusCode, nil
+}
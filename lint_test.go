@@ -0,0 +1,81 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+)
+
+func TestLint(t *testing.T) {
+	media := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "HEAD" {
+			t.Errorf("expected HEAD request; got %s", r.Method)
+		}
+		if r.URL.Path == "/missing.mp3" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer media.Close()
+
+	feed := fmt.Sprintf(`<rss><channel>
+		<item><guid>a</guid><title>One #1</title><enclosure url="%[1]s/1.mp3" type="audio/mpeg"/></item>
+		<item><guid>b</guid><title>Two #1</title><enclosure url="%[1]s/missing.mp3" type="audio/mpeg"/></item>
+		<item><guid>b</guid><title>Repeated</title><enclosure url="%[1]s/2.mp3" type="audio/mpeg"/></item>
+		<item><guid>c</guid><title>%[2]s #3</title><summary>%[3]s</summary></item>
+		<item><guid>d</guid><title>Unnumbered</title><pubDate>someday</pubDate><enclosure url="%[1]s/4.mp3" type="audio/mpeg"/></item>
+		<item><guid>e</guid><title>Broken #5</title><summary>a < b</summary></item>
+	</channel></rss>`, media.URL, strings.Repeat("t", 100), strings.Repeat("d", 5000))
+	path := filepath.Join(t.TempDir(), "feed.xml")
+	if err := os.WriteFile(path, []byte(feed), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := show{feed: path, title: template.Must(template.New("title").Parse("{{.Title}}"))}
+	check := func(ctx context.Context, url string) error {
+		return checkMedia(ctx, media.Client(), url)
+	}
+	problems, err := lint(context.Background(), &podcast.Fetcher{}, s, check)
+	if err != nil {
+		t.Fatalf("could not lint feed: %v", err)
+	}
+
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.Episode+" "+p.Check)
+	}
+	want := []string{
+		" decode",
+		" invalid-date",
+		" duplicate-guid",
+		"b duplicate-number",
+		"d missing-number",
+		"c missing-enclosure",
+		"c title-too-long",
+		"c description-too-long",
+		"b enclosure-status",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected problems:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
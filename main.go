@@ -12,88 +12,345 @@
 // limitations under the License.
 
 // Command podcast-to-youtube generates videos using ffmpeg from any given
-// podcast, by downloading the mp3 and adding a fixed image with a given logo
+// podcast, by downloading the audio and adding a fixed image with a given logo
 // and text.
 package main
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
 	"flag"
 	"fmt"
 	stdimage "image"
 	"image/color"
 	"image/png"
+	"io"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
-	"strconv"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/campoy/podcast-to-youtube/image"
 	"github.com/campoy/podcast-to-youtube/podcast"
+	"github.com/campoy/podcast-to-youtube/state"
 	"github.com/campoy/podcast-to-youtube/youtube"
 	"github.com/campoy/tools/flags"
 	"github.com/microcosm-cc/bluemonday"
 )
 
 var (
-	rssFeed    = flag.String("rss", "http://feeds.feedburner.com/GcpPodcast?format=xml", "URL for the RSS feed")
-	logo       = flag.String("logo", "resources/logo.png", "Path to the logo image. Supports PNG, GIF, and JPEG")
-	font       = flag.String("font", "resources/Roboto-Light.ttf", "Font to be used in the video")
-	titleTmpl  = flags.TextTemplate("title", "{{.Title}}: GCPPodcast {{.Number}}", "Template used for the title")
-	foreground = flags.HexColor("fg", color.White, "Hex encoded color for the video text")
-	background = flags.HexColor("bg", color.RGBA{0, 150, 136, 255}, "Hex encoded color for the video background")
-	width      = flag.Int("w", 1280, "Width of the generated video in pixels")
-	height     = flag.Int("h", 720, "Height of the generated video in pixels")
-	tags       = flag.String("tags", "podcast,gcppodcast", "Comma separated list of tags to use in the YouTube upload")
-	playlist   = flag.String("playlist", "PLIivdWyY5sqJOTOszXDZh3XustjvTsrmQ", "playlist where the videos will be uploaded to")
+	opmlFile      = flag.String("opml", "", "OPML file listing several feeds to publish, instead of -rss; see show.go for the supported outline attributes")
+	rssFeed       = flag.String("rss", "http://feeds.feedburner.com/GcpPodcast?format=xml", "URL or path of the podcast feed (RSS, Atom, or JSON Feed); - reads it from stdin")
+	rssTimeout    = flag.Duration("rss-timeout", 30*time.Second, "Timeout for fetching the podcast feed")
+	stallTimeout  = flag.Duration("download-timeout", time.Minute, "Abandon downloads of episode media that receive no data for this long")
+	cacheDir      = flag.String("cache", "", "Directory where the podcast feed is cached between runs; disabled if empty")
+	maxPages      = flag.Int("max-pages", 1, "Maximum number of pages read from paged or archived feeds (RFC 5005)")
+	mediaTypes    = flag.String("media-types", "audio/mpeg,audio/*", "Comma separated list of preferred enclosure MIME types, most preferred first")
+	lenient       = flag.Bool("lenient", false, "Skip feed items that can not be decoded instead of failing")
+	order         = flag.String("order", string(podcast.OrderNumber), "Order in which episodes are published: number, date, or feed")
+	numberRE      = flag.String("number-regexp", podcast.DefaultTitleNumber.String(), "Regular expression finding episode numbers in titles when the feed has none")
+	stateFile     = flag.String("state", "state.json", "File recording the videos published for every episode; the rebuild-state command recreates it from the playlists")
+	episodes      = flag.String("episodes", "", "Episodes to publish, even if already uploaded, instead of the ones neither in the playlist nor recorded as published or failed (e.g. \"1-10,15\", \"season:2 since:2024-01-01\", \"category:go\", \"title:(?i)interview\", or \"all\"); see selector.go")
+	maxUploads    = flag.Int("max-uploads", 5, "Refuse to publish more episodes than this per show unless they are selected with -episodes, so a new playlist does not get the whole back catalog by mistake; 0 means no limit")
+	logo          = flag.String("logo", "resources/logo.png", "Path to the logo image. Supports PNG, GIF, and JPEG")
+	font          = flag.String("font", "resources/Roboto-Light.ttf", "Font to be used in the video")
+	titleTmpl     = flags.TextTemplate("title", "{{.Title}}: GCPPodcast {{.Number}}", "Template used for the title, executed on a podcast.Episode (e.g. {{.Podcast.Title}} or {{.Published.Format \"2006-01-02\"}})")
+	foreground    = flags.HexColor("fg", color.White, "Hex encoded color for the video text")
+	background    = flags.HexColor("bg", color.RGBA{0, 150, 136, 255}, "Hex encoded color for the video background")
+	width         = flag.Int("w", 1280, "Width of the generated video in pixels")
+	height        = flag.Int("h", 720, "Height of the generated video in pixels")
+	tags          = flag.String("tags", "podcast,gcppodcast", "Comma separated list of tags to use in the YouTube upload")
+	sortPlaylist  = flag.String("sort-playlist", "", "If set, sort the playlist after publishing by number or date, oldest first, or by -number or -date, newest first")
+	privacy       = flag.String("privacy", youtube.Public, "Privacy status of the uploaded videos: public, unlisted, or private")
+	publishAt     = flag.String("publish-at", "now", "When videos are made public: now, pubdate for the episode publication date, or a policy like \"next Tuesday 09:00 Europe/Madrid\"; see release.go")
+	category      = flag.String("category", "", "YouTube category ID of the videos, like 28 for Science & Technology")
+	language      = flag.String("language", "", "Language of the video titles and descriptions, like en")
+	audioLanguage = flag.String("audio-language", "", "Language spoken in the videos, like en-US; defaults to the language of the feed")
+	license       = flag.String("license", "", "License of the videos: youtube or creativeCommon")
+	embeddable    = optionalBoolFlag("embeddable", "Whether the videos can be embedded in other sites")
+	publicStats   = optionalBoolFlag("public-stats", "Whether the statistics of the videos are public")
+	madeForKids   = optionalBoolFlag("made-for-kids", "Whether the videos are made for kids")
+	recordingDate = flag.Bool("recording-date", false, "Set the recording date of the videos to the publication date of their episodes")
+	metadataFile  = flag.String("metadata", "", "JSON file with the metadata of the videos, and overrides by episode; see metadata.go")
+	thumbnail     = flag.Bool("thumbnail", true, "Set a thumbnail showing the episode artwork, number, and title; needs a verified YouTube account")
+	captions      = flag.Bool("captions", true, "Add the transcripts of the episodes as captions of their videos")
+	workDir       = flag.String("work-dir", "work", "Directory where videos are kept until uploaded, so interrupted uploads can resume")
+	processing    = flag.Duration("processing-timeout", youtube.DefaultProcessingTimeout, "How long to wait for YouTube to process a video; publication resumes on the next run if it takes longer")
+	pollInterval  = flag.Duration("poll-interval", youtube.DefaultPollInterval, "How long to wait before checking whether a video is processed, doubled after every check")
+	chunkSize     = flag.Int64("chunk-size", youtube.DefaultChunkSize, "Size in bytes of the chunks in which videos are uploaded, rounded up to a multiple of 256 KiB")
+	playlist      = flag.String("playlist", "PLIivdWyY5sqJOTOszXDZh3XustjvTsrmQ", "playlist where the videos will be uploaded to")
+)
+
+// The release policy given with -publish-at, and the metadata read from the
+// file given with -metadata.
+var (
+	release  releasePolicy
+	metadata metadataConfig
 )
 
 func main() {
 	flag.Parse()
 
+	titleNumber, err := regexp.Compile(*numberRE)
+	if err != nil {
+		failf("invalid -number-regexp: %v\n", err)
+	}
+
+	fetcher := &podcast.Fetcher{
+		Client:      &http.Client{Timeout: *rssTimeout},
+		CacheDir:    *cacheDir,
+		TitleNumber: titleNumber,
+		Order:       podcast.Order(*order),
+		MaxPages:    *maxPages,
+		Lenient:     *lenient,
+		Report:      func(d podcast.Diagnostic) { log.Print(d) },
+		Enclosures:  podcast.EnclosurePolicy{Types: strings.Split(*mediaTypes, ",")},
+	}
+
+	if err := checkPrivacy(*privacy); err != nil {
+		failf("invalid -privacy: %v\n", err)
+	}
+	if release, err = parseRelease(*publishAt); err != nil {
+		failf("invalid -publish-at: %v\n", err)
+	}
+
+	switch *license {
+	case "", youtube.LicenseYouTube, youtube.LicenseCreativeCommon:
+	default:
+		failf("invalid -license %q; use youtube or creativeCommon\n", *license)
+	}
+	if *metadataFile != "" {
+		if metadata, err = loadMetadata(*metadataFile); err != nil {
+			failf("%v\n", err)
+		}
+	}
+
+	shows := []show{defaultShow()}
+	if *opmlFile != "" {
+		if shows, err = loadShows(*opmlFile); err != nil {
+			failf("%v\n", err)
+		}
+	}
+
+	var sel selector
+	if *episodes != "" {
+		if sel, err = parseSelector(*episodes); err != nil {
+			failf("invalid -episodes: %v\n", err)
+		}
+	}
+
+	if *sortPlaylist != "" {
+		if _, err := sortEpisodes(nil, *sortPlaylist); err != nil {
+			failf("invalid -sort-playlist: %v\n", err)
+		}
+	}
+
+	switch flag.Arg(0) {
+	case "", "rebuild-state", "reconcile":
+	case "lint":
+		os.Exit(runLint(fetcher, shows, flag.Args()[1:]))
+	default:
+		failf("unknown command %q\n", flag.Arg(0))
+	}
+
 	client, err := youtube.NewClient("client_secret.json", "token.json", log.Printf)
 	if err != nil {
 		failf("could not authenticate with YouTube: %v\n", err)
 	}
+	client.ChunkSize = *chunkSize
+	client.PollInterval = *pollInterval
+	client.ProcessingTimeout = *processing
+	client.Progress = func(sent, total int64) {
+		if total > 0 {
+			log.Printf("uploaded %d of %d MiB (%d%%)", sent>>20, total>>20, 100*sent/total)
+		}
+	}
 
-	eps, err := podcast.FetchFeed(*rssFeed)
+	// Tokens authorized before captions were supported can not upload them,
+	// so we check before uploading anything.
+	if *captions && flag.Arg(0) == "" {
+		ok, err := client.HasScope(context.Background(), youtube.CaptionScope)
+		if err != nil {
+			log.Printf("could not check the permissions of token.json: %v", err)
+		} else if !ok {
+			failf("token.json does not allow uploading captions: delete it and authorize again, or use -captions=false\n")
+		}
+	}
+
+	store, err := state.Open(*stateFile)
 	if err != nil {
 		failf("%v\n", err)
 	}
 
-	last, err := client.FetchLastPublished(*playlist)
-	if err != nil {
-		failf("%s", err)
+	switch flag.Arg(0) {
+	case "rebuild-state":
+		forEachShow(shows, func(s show) error {
+			eps, err := fetcher.Fetch(context.Background(), s.feed)
+			if err != nil {
+				return err
+			}
+			return rebuild(client, store, s, eps)
+		})
+	case "reconcile":
+		os.Exit(runReconcile(client, fetcher, store, shows, flag.Args()[1:]))
+	default:
+		forEachShow(shows, func(s show) error {
+			return sync(client, fetcher, store, s, sel)
+		})
 	}
+}
 
-	for i := len(eps) - 1; i >= 0; i-- {
-		if strings.HasSuffix(last.Snippet.Title, fmt.Sprint(eps[i].Number)) {
-			eps = eps[i+1:]
-			break
+// forEachShow calls f for each of the given shows, and exits with status 1
+// if any of the calls failed. A failure in one show does not prevent handling
+// the following ones.
+func forEachShow(shows []show, f func(s show) error) {
+	failed := false
+	for _, s := range shows {
+		if err := f(s); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", s.feed, err)
+			failed = true
 		}
 	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// sync publishes the episodes of the given show selected by sel or, if sel is
+// nil, the ones that are neither recorded as published or failed in the store
+// nor found in the playlist of the show.
+func sync(client *youtube.Client, fetcher *podcast.Fetcher, store *state.Store, s show, sel selector) error {
+	ctx := context.Background()
+	eps, err := fetcher.Fetch(ctx, s.feed)
+	if err != nil {
+		return err
+	}
 
-	if len(eps) == 0 {
-		fmt.Println("everything up to date")
-		return
+	items, err := client.PlaylistItems(ctx, s.playlist)
+	if err != nil {
+		return err
 	}
+	ix := indexPlaylist(items, eps)
 
-	fmt.Println("about to publish:")
+	// Episodes found in the playlist are recorded instead of uploaded again,
+	// for instance if they were published by another installation.
 	for _, ep := range eps {
-		fmt.Printf("#%d: %s\n", ep.Number, ep.Title)
+		if vids := ix.videos[ep.ID]; len(vids) > 0 && !store.Published(ep.ID) {
+			if err := store.Put(playlistRecord(s, ep, vids[0])); err != nil {
+				return err
+			}
+		}
 	}
 
+	var selected []podcast.Episode
+	if sel != nil {
+		for _, ep := range eps {
+			if sel(ep) {
+				selected = append(selected, ep)
+			}
+		}
+	} else {
+		// Episodes removed from the playlist after being published are left
+		// to the reconcile command.
+		for _, ep := range ix.missing(eps) {
+			switch {
+			case store.Published(ep.ID):
+			case store.Failed(ep.ID):
+				rec, _ := store.Get(ep.ID)
+				log.Printf("skipping episode %d: video %s %s; select it with -episodes to upload it again", ep.Number, rec.VideoID, rec.Reason)
+			default:
+				selected = append(selected, ep)
+			}
+		}
+	}
+
+	if sel == nil && *maxUploads > 0 && len(selected) > *maxUploads {
+		return fmt.Errorf("%d episodes are missing from playlist %s; publish them with -episodes all, or raise -max-uploads", len(selected), s.playlist)
+	}
+
+	if len(selected) == 0 {
+		fmt.Printf("%s: everything up to date\n", s.feed)
+	} else {
+		fmt.Printf("%s: about to publish:\n", s.feed)
+		for _, ep := range selected {
+			fmt.Printf("#%d: %s (number from %s)\n", ep.Number, ep.Title, ep.NumberSource)
+		}
+	}
+
+	// Episodes published with problems, or that YouTube did not process,
+	// are reported once all the others are published too.
+	var problems []string
+	for _, ep := range selected {
+		err := process(client, fetcher, store, s, ep)
+		var (
+			cerr *captionError
+			perr *youtube.ProcessingError
+		)
+		if errors.As(err, &cerr) || errors.As(err, &perr) || errors.Is(err, youtube.ErrStillProcessing) {
+			log.Printf("episode %d: %v", ep.Number, err)
+			problems = append(problems, fmt.Sprintf("episode %d: %v", ep.Number, err))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("episode %d: %v", ep.Number, err)
+		}
+	}
+
+	if *sortPlaylist != "" {
+		if err := sortShowPlaylist(ctx, client, s, eps); err != nil {
+			return err
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// sortShowPlaylist sorts the playlist of the given show as requested by
+// -sort-playlist. The videos that do not belong to any of the given episodes
+// are moved to the end of the playlist.
+func sortShowPlaylist(ctx context.Context, client *youtube.Client, s show, eps []podcast.Episode) error {
+	items, err := client.PlaylistItems(ctx, s.playlist)
+	if err != nil {
+		return err
+	}
+	sorted, err := sortEpisodes(eps, *sortPlaylist)
+	if err != nil {
+		return err
+	}
+	n, err := client.Reorder(ctx, s.playlist, indexPlaylist(items, eps).firstVideos(sorted))
+	if n > 0 {
+		log.Printf("moved %d videos in playlist %s", n, s.playlist)
+	}
+	return err
+}
+
+// rebuild replaces the records of the playlist of the given show in the store
+// with the videos found in the playlist that were generated from any of the
+// given episodes.
+func rebuild(client *youtube.Client, store *state.Store, s show, eps []podcast.Episode) error {
+	items, err := client.PlaylistItems(context.Background(), s.playlist)
+	if err != nil {
+		return err
+	}
+	ix := indexPlaylist(items, eps)
+
+	var records []state.Record
 	for _, ep := range eps {
-		if err := process(client, ep); err != nil {
-			failf("episode %d: %v\n", ep.Number, err)
+		if vids := ix.videos[ep.ID]; len(vids) > 0 {
+			records = append(records, playlistRecord(s, ep, vids[0]))
 		}
 	}
+	log.Printf("found %d episodes in the %d videos of playlist %s", len(records), len(items), s.playlist)
+	return store.Reset(s.playlist, records)
 }
 
 func failf(s string, args ...interface{}) {
@@ -101,28 +358,147 @@ func failf(s string, args ...interface{}) {
 	os.Exit(1)
 }
 
-// parseRange parses either a range (n-m) or a single episode number (n)
-// and returns the first and last elements of the range.
-func parseRange(s string) (first, last int, err error) {
-	switch ps := strings.Split(s, "-"); len(ps) {
-	case 1:
-		n, err := strconv.Atoi(ps[0])
-		return n, n, err
-	case 2:
-		from, err := strconv.Atoi(ps[0])
+// process publishes the given episode of a show, resuming the publication
+// recorded in the store if it did not complete.
+func process(client *youtube.Client, fetcher *podcast.Fetcher, store *state.Store, s show, ep podcast.Episode) error {
+	rec, ok := store.Get(ep.ID)
+	if ok && rec.Status != state.StatusPublished && rec.Status != state.StatusFailed && rec.Playlist == s.playlist {
+		log.Printf("resuming publication of video %s", rec.VideoID)
+	} else {
+		var err error
+		if rec, err = upload(client, store, s, ep); err != nil {
+			return err
+		}
+	}
+
+	var captionErr error
+	if rec.Status == state.StatusUploaded {
+		log.Printf("video uploaded; waiting to be processed")
+
+		// A video still being processed is checked again on the next run,
+		// while one that YouTube gave up on is recorded as failed, so it is
+		// skipped unless selected explicitly.
+		err := client.WaitUntilProcessed(context.Background(), rec.VideoID)
+		var perr *youtube.ProcessingError
+		if errors.As(err, &perr) {
+			rec.Status, rec.Reason = state.StatusFailed, perr.Status
+			if perr.Reason != "" {
+				rec.Reason += ": " + perr.Reason
+			}
+			if err := store.Put(rec); err != nil {
+				return err
+			}
+			return fmt.Errorf("%w; select the episode with -episodes to upload it again", err)
+		}
+		if errors.Is(err, youtube.ErrStillProcessing) {
+			return fmt.Errorf("%w; run again later to publish video %s", youtube.ErrStillProcessing, rec.VideoID)
+		}
 		if err != nil {
-			return 0, 0, err
+			return err
 		}
-		to, err := strconv.Atoi(ps[1])
-		return from, to, err
-	default:
-		return 0, 0, errors.New("only formats supported are n or m-n")
+
+		// Captions can only be added once the video is processed. Missing
+		// captions do not prevent publishing the video, but are reported.
+		if *captions {
+			var n int
+			n, captionErr = addCaptions(client, fetcher, s, ep, rec.VideoID)
+			if n > 0 {
+				log.Printf("added %d caption tracks to video %s", n, rec.VideoID)
+			}
+		}
+		rec.Status = state.StatusProcessed
+		if err := store.Put(rec); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("video processed; now adding it to the playlist")
+
+	if err := client.AddToPlaylist(s.playlist, rec.VideoID); err != nil {
+		return fmt.Errorf("could not insert into playlist: %v", err)
+	}
+	rec.Status = state.StatusPublished
+	if err := store.Put(rec); err != nil {
+		return err
 	}
+	return captionErr
 }
 
-// process creates the video for the given episode and uploads it
-// to YouTube using an authenticated HTTP client.
-func process(client *youtube.Client, ep podcast.Episode) error {
+// upload creates the video for the given episode of a show and uploads it
+// to YouTube using an authenticated HTTP client, recording it in the store.
+// The video is kept in the work directory until it is recorded, so an
+// interrupted upload can resume.
+func upload(client *youtube.Client, store *state.Store, s show, ep podcast.Episode) (state.Record, error) {
+	rec := state.Record{EpisodeID: ep.ID, Playlist: s.playlist, Status: state.StatusUploaded}
+
+	dir := filepath.Join(*workDir, fmt.Sprintf("%x", sha256.Sum256([]byte(ep.ID))))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return rec, fmt.Errorf("could not create work directory: %v", err)
+	}
+
+	// The hash of the audio is written once the video is complete.
+	vid := filepath.Join(dir, "vid.mp4")
+	hash, err := ioutil.ReadFile(vid + ".sha256")
+	if err == nil {
+		log.Printf("reusing video rendered in %s", dir)
+		rec.AudioHash = string(hash)
+	} else {
+		if rec.AudioHash, err = render(s, ep, vid); err != nil {
+			return rec, err
+		}
+		if err := ioutil.WriteFile(vid+".sha256", []byte(rec.AudioHash), 0644); err != nil {
+			return rec, fmt.Errorf("could not write audio hash: %v", err)
+		}
+	}
+
+	// We generate the metadata for the YouTube upload.
+	title, err := s.videoTitle(ep)
+	if err != nil {
+		return rec, err
+	}
+	v := youtube.Video{
+		Title:       title,
+		Description: videoDescription(ep),
+		Tags:        append(ep.Tags, s.tags...),
+		Privacy:     s.privacy,
+		PublishAt:   s.release(ep, time.Now()),
+		Metadata:    episodeMetadata(s, ep),
+	}
+
+	if v.PublishAt.IsZero() {
+		log.Printf("uploading video")
+	} else {
+		log.Printf("uploading video to be published at %v", v.PublishAt)
+	}
+
+	// And finally we upload the video to YouTube.
+	rec.VideoID, err = client.Upload(v, vid)
+	if err != nil {
+		return rec, fmt.Errorf("could not upload to YouTube: %v", err)
+	}
+	rec.Uploaded = time.Now()
+	if err := store.Put(rec); err != nil {
+		return rec, err
+	}
+
+	// A missing thumbnail is not worth failing the upload, since YouTube
+	// picks one from the video.
+	if *thumbnail {
+		if err := setThumbnail(client, s, ep, rec.VideoID); err != nil {
+			log.Printf("could not set thumbnail of video %s: %v", rec.VideoID, err)
+		}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		log.Printf("could not remove %s: %v", dir, err)
+	}
+	return rec, nil
+}
+
+// setThumbnail generates the thumbnail of the given episode of a show, with
+// the artwork of the episode, the podcast, or the show logo, and sets it as the
+// thumbnail of the given video.
+func setThumbnail(client *youtube.Client, s show, ep podcast.Episode, video string) error {
 	tmp, err := ioutil.TempDir("", "")
 	if err != nil {
 		return fmt.Errorf("could not create temp directory: %v", err)
@@ -133,19 +509,75 @@ func process(client *youtube.Client, ep podcast.Episode) error {
 		}
 	}()
 
+	artwork := ep.Image
+	if artwork == "" && ep.Podcast != nil {
+		artwork = ep.Podcast.Image
+	}
+	if artwork != "" {
+		path := filepath.Join(tmp, "artwork")
+		if _, err := download(artwork, path); err != nil {
+			return err
+		}
+		artwork = path
+	} else {
+		artwork = s.logo
+	}
+
+	img, err := image.Thumbnail(image.ThumbnailParams{
+		Artwork:    artwork,
+		Number:     ep.Number,
+		Title:      ep.Title,
+		Font:       *font,
+		Foreground: s.foreground,
+		Background: s.background,
+		Width:      *width,
+		Height:     *height,
+	})
+	if err != nil {
+		return fmt.Errorf("could not generate thumbnail: %v", err)
+	}
+
+	path := filepath.Join(tmp, "thumbnail.jpg")
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create %s: %v", path, err)
+	}
+	if err := image.EncodeThumbnail(f, img, image.MaxThumbnailSize); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("could not write %s: %v", path, err)
+	}
+	return client.SetThumbnail(video, path)
+}
+
+// render creates the video for the given episode of a show at the given
+// path, and returns the hex encoded SHA-256 hash of the audio it contains.
+func render(s show, ep podcast.Episode, vid string) (string, error) {
+	tmp, err := ioutil.TempDir("", "")
+	if err != nil {
+		return "", fmt.Errorf("could not create temp directory: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmp); err != nil {
+			log.Printf("could not remove %s: %v", tmp, err)
+		}
+	}()
+
 	log.Printf("creating background image")
 
 	img, err := image.Generate(image.Params{
-		Logo:       *logo,
+		Logo:       s.logo,
 		Text:       fmt.Sprintf("%d: %s", ep.Number, ep.Title),
 		Font:       *font,
-		Foreground: foreground,
-		Background: background,
+		Foreground: s.foreground,
+		Background: s.background,
 		Width:      *width,
 		Height:     *height,
 	})
 	if err != nil {
-		return fmt.Errorf("could not generate image: %v", err)
+		return "", fmt.Errorf("could not generate image: %v", err)
 	}
 
 	log.Printf("background image created")
@@ -153,55 +585,119 @@ func process(client *youtube.Client, ep podcast.Episode) error {
 	// We create the image and store it in the temp directory.
 	slide := filepath.Join(tmp, "slide.png")
 	if err := writePNG(slide, img); err != nil {
-		return fmt.Errorf("could not create image: %v", err)
+		return "", fmt.Errorf("could not create image: %v", err)
 	}
 
-	log.Printf("rendering video")
+	log.Printf("downloading audio")
 
-	// Then we create the video.
-	vid := filepath.Join(tmp, "vid.mp4")
-	if err := ffmpeg(slide, ep.MP3, vid); err != nil {
-		return fmt.Errorf("could not create video: %v", err)
+	// We download the audio, hashing it to record which version was published.
+	if ep.Media == "" {
+		return "", fmt.Errorf("no audio enclosure found")
 	}
-
-	// We generate the metadata for the YouTube upload.
-	var buf bytes.Buffer
-	if err := titleTmpl.Execute(&buf, ep); err != nil {
-		return fmt.Errorf("could not create video title from template: %v", err)
+	audio := filepath.Join(tmp, "audio"+path.Ext(ep.Media))
+	hash, err := download(ep.Media, audio)
+	if err != nil {
+		return "", err
 	}
 
-	title := buf.String()
-	tags := append(ep.Tags, strings.Split(*tags, ",")...)
+	log.Printf("rendering video")
 
-	// We drop all the HTML tags and line breaks from the description.
-	desc := bluemonday.StrictPolicy().Sanitize(ep.Desc)
-	desc = strings.Replace(desc, "\n", " ", -1)
-	desc = fmt.Sprintf("Original post: %s\n\n", ep.Link) + desc
+	// Then we create the video, which is only moved to its final path once
+	// complete.
+	out := vid + ".tmp.mp4"
+	if err := ffmpeg(slide, audio, out); err != nil {
+		return "", fmt.Errorf("could not create video: %v", err)
+	}
+	if err := os.Rename(out, vid); err != nil {
+		return "", fmt.Errorf("could not move video: %v", err)
+	}
+	return hash, nil
+}
 
-	log.Printf("uploading video")
+// download copies the media at the given URL or local path to dst, and returns
+// its hex encoded SHA-256 hash. Downloads that receive no data for
+// -download-timeout are abandoned.
+func download(src, dst string) (string, error) {
+	var r io.ReadCloser
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		req, err := http.NewRequest("GET", src, nil)
+		if err != nil {
+			return "", fmt.Errorf("could not create request for %s: %v", src, err)
+		}
+		stall := time.AfterFunc(*stallTimeout, cancel)
+		defer stall.Stop()
 
-	// And finally we upload the video to YouTube.
-	video, err := client.Upload(title, desc, tags, vid)
-	if err != nil {
-		return fmt.Errorf("could not upload to YouTube: %v", err)
+		res, err := http.DefaultClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return "", fmt.Errorf("could not download %s: %v", src, stallError(ctx, err))
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return "", fmt.Errorf("could not download %s: %s", src, res.Status)
+		}
+		r = stallReader{res.Body, stall, ctx}
+	} else {
+		f, err := os.Open(strings.TrimPrefix(src, "file://"))
+		if err != nil {
+			return "", fmt.Errorf("could not open %s: %v", src, err)
+		}
+		r = f
 	}
+	defer r.Close()
 
-	log.Printf("video uploaded; waiting to be processed")
+	f, err := os.Create(dst)
+	if err != nil {
+		return "", fmt.Errorf("could not create %s: %v", dst, err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), r); err != nil {
+		f.Close()
+		return "", fmt.Errorf("could not download %s: %v", src, err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("could not write %s: %v", dst, err)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// A stallReader resets a timer every time it reads some data, and reports
+// read errors caused by the timer canceling its context as stalls.
+type stallReader struct {
+	io.ReadCloser
+	timer *time.Timer
+	ctx   context.Context
+}
 
-	if err := client.WaitUntilProcessed(ctx, video); err != nil {
-		return err
+func (r stallReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.timer.Reset(*stallTimeout)
 	}
+	if err != nil && err != io.EOF {
+		err = stallError(r.ctx, err)
+	}
+	return n, err
+}
 
-	log.Printf("video processed; now adding it to the playlist")
-
-	err = client.AddToPlaylist(*playlist, video)
-	if err != nil {
-		return fmt.Errorf("could not insert into playlist: %v", err)
+// stallError returns a clearer error than err if ctx was canceled because a
+// download stalled.
+func stallError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("no data received for %v", *stallTimeout)
 	}
-	return nil
+	return err
+}
+
+// videoDescription returns the description of the video for the given
+// episode. We drop all the HTML tags and line breaks from the episode
+// description, and add the link to the original post and the episode ID.
+func videoDescription(ep podcast.Episode) string {
+	desc := bluemonday.StrictPolicy().Sanitize(ep.Desc)
+	desc = strings.Replace(desc, "\n", " ", -1)
+	desc = fmt.Sprintf("Original post: %s\n\n", ep.Link) + desc
+	return desc + "\n\n" + episodeIDPrefix + ep.ID
 }
 
 // writePNG encodes the given image as a PNG file at the given path.
@@ -223,12 +719,12 @@ func writePNG(path string, img stdimage.Image) error {
 
 // ffmpeg creates a video at the filepath vid. The generated video
 // has the image at the the img filepath as fixed background and plays the
-// audio at the mp3 filepath.
+// audio at the given filepath or URL.
 // This function requires ffmpeg to be installed.
 // See https://ffmpeg.org for installation instructions.
-func ffmpeg(img, mp3, vid string) error {
+func ffmpeg(img, audio, vid string) error {
 	// ffmpeg -y -i slide.png -i audio.mp3 -pix_fmt yuv420p -c:a aac -c:v libx264 -crf 18 out.mp4
-	cmd := exec.Command("ffmpeg", "-y", "-loop", "1", "-i", img, "-i", mp3, "-shortest",
+	cmd := exec.Command("ffmpeg", "-y", "-loop", "1", "-i", img, "-i", audio, "-shortest",
 		"-c:v", "libx264", "-pix_fmt", "yuv420p", "-c:a", "aac", "-crf", "18",
 		vid)
 	cmd.Stdout = os.Stdout
@@ -0,0 +1,152 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+	"github.com/campoy/podcast-to-youtube/state"
+	"github.com/campoy/podcast-to-youtube/youtube"
+)
+
+func TestDownloadStall(t *testing.T) {
+	defer func(d time.Duration) { *stallTimeout = d }(*stallTimeout)
+	*stallTimeout = 50 * time.Millisecond
+
+	release := make(chan bool)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("some audio"))
+		w.(http.Flusher).Flush()
+		if r.URL.Path == "/stall" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}
+	}))
+	defer s.Close()
+	defer close(release)
+
+	dst := filepath.Join(t.TempDir(), "audio")
+	if _, err := download(s.URL+"/ok", dst); err != nil {
+		t.Errorf("could not download: %v", err)
+	}
+	_, err := download(s.URL+"/stall", dst)
+	if err == nil || !strings.Contains(err.Error(), "no data received") {
+		t.Errorf("expected a stalled download error; got %v", err)
+	}
+}
+
+// redirect sends every request to the server at url.
+type redirect struct{ url *url.URL }
+
+func (r redirect) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme, req.URL.Host = r.url.Scheme, r.url.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestSyncRejectedVideo(t *testing.T) {
+	checks := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<rss version="2.0"><channel><title>Show</title><item>
+			<title>Episode #1</title><guid>ep-1</guid>
+			<enclosure url="http://example.com/1.mp3" type="audio/mpeg" length="1"/>
+		</item></channel></rss>`)
+	})
+	mux.HandleFunc("/youtube/v3/playlistItems", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"items": []}`)
+	})
+	mux.HandleFunc("/youtube/v3/videos", func(w http.ResponseWriter, r *http.Request) {
+		checks++
+		fmt.Fprint(w, `{"items": [{"status": {"uploadStatus": "rejected", "rejectionReason": "duplicate"}}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	client, err := youtube.NewClientFromHTTP(&http.Client{Transport: redirect{u}}, t.Logf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.PollInterval = time.Millisecond
+
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploaded := state.Record{EpisodeID: "ep-1", Playlist: "PL", VideoID: "vid", Status: state.StatusUploaded}
+	if err := store.Put(uploaded); err != nil {
+		t.Fatal(err)
+	}
+
+	s := show{feed: srv.URL + "/feed.xml", playlist: "PL"}
+	err = sync(client, &podcast.Fetcher{}, store, s, nil)
+	if err == nil || !strings.Contains(err.Error(), "rejected: duplicate") {
+		t.Errorf("expected the rejection to be reported; got %v", err)
+	}
+	if rec, _ := store.Get("ep-1"); rec.Status != state.StatusFailed || rec.Reason != "rejected: duplicate" {
+		t.Errorf("expected the record to be marked as failed; got %+v", rec)
+	}
+
+	// The failed episode is not retried, so it does not block the others.
+	if err := sync(client, &podcast.Fetcher{}, store, s, nil); err != nil {
+		t.Errorf("expected the failed episode to be skipped; got %v", err)
+	}
+	if checks != 1 {
+		t.Errorf("expected the video to be checked once; got %d checks", checks)
+	}
+}
+
+func TestSyncTooManyUploads(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<rss version="2.0"><channel><title>Show</title>`)
+		for i := 1; i <= 3; i++ {
+			fmt.Fprintf(w, `<item><title>Episode #%d</title><guid>ep-%[1]d</guid></item>`, i)
+		}
+		fmt.Fprint(w, `</channel></rss>`)
+	})
+	mux.HandleFunc("/youtube/v3/playlistItems", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"items": []}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	client, err := youtube.NewClientFromHTTP(&http.Client{Transport: redirect{u}}, t.Logf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(n int) { *maxUploads = n }(*maxUploads)
+	*maxUploads = 2
+	s := show{feed: srv.URL + "/feed.xml", playlist: "PL"}
+	err = sync(client, &podcast.Fetcher{}, store, s, nil)
+	if err == nil || !strings.Contains(err.Error(), "3 episodes are missing") {
+		t.Errorf("expected sync to refuse publishing 3 episodes; got %v", err)
+	}
+}
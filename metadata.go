@@ -0,0 +1,136 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+	"github.com/campoy/podcast-to-youtube/youtube"
+)
+
+// An optionalBool is a boolean flag that can be left unset.
+type optionalBool struct{ v *bool }
+
+// optionalBoolFlag defines an optionalBool flag with the given name and usage.
+func optionalBoolFlag(name, usage string) *optionalBool {
+	b := new(optionalBool)
+	flag.Var(b, name, usage)
+	return b
+}
+
+func (b *optionalBool) IsBoolFlag() bool { return true }
+
+func (b *optionalBool) String() string {
+	if b == nil || b.v == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b.v)
+}
+
+func (b *optionalBool) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	b.v = &v
+	return nil
+}
+
+// A metadataConfig contains the metadata of the videos of a show, read from
+// a JSON file like:
+//
+//	{
+//		"categoryId": "28",
+//		"defaultAudioLanguage": "en",
+//		"episodes": {
+//			"42": {"defaultAudioLanguage": "es"},
+//			"tag:example.com,2024:episode-43": {"selfDeclaredMadeForKids": true}
+//		}
+//	}
+//
+// The keys of episodes are episode numbers or IDs. See youtube.Metadata for
+// the other fields.
+type metadataConfig struct {
+	youtube.Metadata
+	Episodes map[string]youtube.Metadata `json:"episodes"`
+}
+
+// loadMetadata reads the metadata configuration in the given file.
+func loadMetadata(path string) (metadataConfig, error) {
+	var c metadataConfig
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return c, fmt.Errorf("could not read metadata: %v", err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("could not decode %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// flagMetadata returns the metadata given with command line flags.
+func flagMetadata() youtube.Metadata {
+	return youtube.Metadata{
+		CategoryID:           *category,
+		DefaultLanguage:      *language,
+		DefaultAudioLanguage: *audioLanguage,
+		License:              *license,
+		Embeddable:           embeddable.v,
+		PublicStatsViewable:  publicStats.v,
+		MadeForKids:          madeForKids.v,
+	}
+}
+
+// episodeMetadata returns the metadata of the video of the given episode of
+// a show. The command line flags override the configuration of the show, and
+// the overrides of the episode, by number and then by ID, override both.
+// The audio language defaults to the language of the podcast.
+func episodeMetadata(s show, ep podcast.Episode) youtube.Metadata {
+	m := s.metadata.Metadata.Override(flagMetadata())
+	if m.DefaultAudioLanguage == "" && ep.Podcast != nil {
+		m.DefaultAudioLanguage = languageTag(ep.Podcast.Language)
+	}
+	if *recordingDate {
+		m.RecordingDate = ep.Published
+	}
+	if o, ok := s.metadata.Episodes[strconv.Itoa(ep.Number)]; ok {
+		m = m.Override(o)
+	}
+	if o, ok := s.metadata.Episodes[ep.ID]; ok {
+		m = m.Override(o)
+	}
+	return m
+}
+
+// languageTag formats a language code, as found in feeds, like the ones
+// accepted by YouTube: "en-us" becomes "en-US".
+func languageTag(lang string) string {
+	parts := strings.Split(strings.Replace(strings.TrimSpace(lang), "_", "-", -1), "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch len(parts[i]) {
+		case 2:
+			parts[i] = strings.ToUpper(parts[i])
+		case 4:
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		}
+	}
+	return strings.Join(parts, "-")
+}
@@ -0,0 +1,72 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+)
+
+func TestEpisodeMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.json")
+	config := `{
+		"categoryId": "28",
+		"license": "creativeCommon",
+		"episodes": {
+			"2": {"defaultAudioLanguage": "es", "selfDeclaredMadeForKids": true},
+			"guid-3": {"categoryId": "27"}
+		}
+	}`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := loadMetadata(path)
+	if err != nil {
+		t.Fatalf("could not load metadata: %v", err)
+	}
+
+	*license = "youtube"
+	defer func() { *license = "" }()
+
+	s := show{metadata: c}
+	p := &podcast.Podcast{Language: "en-us"}
+	tests := []struct {
+		ep                      podcast.Episode
+		category, lang, license string
+		kids                    bool
+	}{
+		{podcast.Episode{ID: "guid-1", Number: 1, Podcast: p}, "28", "en-US", "youtube", false},
+		{podcast.Episode{ID: "guid-2", Number: 2, Podcast: p}, "28", "es", "youtube", true},
+		{podcast.Episode{ID: "guid-3", Number: 3}, "27", "", "youtube", false},
+	}
+	for _, tt := range tests {
+		m := episodeMetadata(s, tt.ep)
+		kids := m.MadeForKids != nil && *m.MadeForKids
+		if m.CategoryID != tt.category || m.DefaultAudioLanguage != tt.lang || m.License != tt.license || kids != tt.kids {
+			t.Errorf("episode %s: expected category %q, language %q, license %q, and made for kids %v; got %+v",
+				tt.ep.ID, tt.category, tt.lang, tt.license, tt.kids, m)
+		}
+	}
+}
+
+func TestLanguageTag(t *testing.T) {
+	for lang, want := range map[string]string{"en": "en", "EN-us": "en-US", "zh_hant_tw": "zh-Hant-TW", "es-419": "es-419"} {
+		if got := languageTag(lang); got != want {
+			t.Errorf("languageTag(%q) = %q; expected %q", lang, got, want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+	"github.com/campoy/podcast-to-youtube/state"
+	"github.com/campoy/podcast-to-youtube/youtube"
+)
+
+// episodeIDPrefix starts the line identifying the episode a video was
+// generated from, which is added to the description of every upload.
+const episodeIDPrefix = "Episode ID: "
+
+// episodeID returns the ID of the episode a video was generated from, as
+// found in its description, if any.
+func episodeID(desc string) (string, bool) {
+	i := strings.LastIndex(desc, episodeIDPrefix)
+	if i < 0 {
+		return "", false
+	}
+	id := desc[i+len(episodeIDPrefix):]
+	if j := strings.Index(id, "\n"); j >= 0 {
+		id = id[:j]
+	}
+	return strings.TrimSpace(id), true
+}
+
+// isEpisode reports whether the video with the given title and description
+// was generated from the given episode. Videos uploaded before episode IDs
+// were added to descriptions are matched by the number ending their title.
+func isEpisode(title, desc string, ep podcast.Episode) bool {
+	if id, ok := episodeID(desc); ok {
+		return id == ep.ID
+	}
+	// The number must not be the end of a larger one: 11 is not episode 1.
+	n := fmt.Sprint(ep.Number)
+	if !strings.HasSuffix(title, n) {
+		return false
+	}
+	rest := strings.TrimSuffix(title, n)
+	return rest == "" || rest[len(rest)-1] < '0' || rest[len(rest)-1] > '9'
+}
+
+// A playlistIndex maps the videos of a playlist to the episodes they were
+// generated from.
+type playlistIndex struct {
+	videos    map[string][]youtube.PlaylistItem // By episode ID, in playlist order.
+	unmatched []youtube.PlaylistItem            // Videos not generated from any episode.
+}
+
+// indexPlaylist maps the given playlist items to the given episodes.
+func indexPlaylist(items []youtube.PlaylistItem, eps []podcast.Episode) playlistIndex {
+	ix := playlistIndex{videos: make(map[string][]youtube.PlaylistItem)}
+	known := make(map[string]bool)
+	for _, ep := range eps {
+		known[ep.ID] = true
+	}
+
+	for _, item := range items {
+		id, ok := episodeID(item.Description)
+		if ok && !known[id] {
+			ok = false
+		} else if !ok {
+			for _, ep := range eps {
+				if isEpisode(item.Title, item.Description, ep) {
+					id, ok = ep.ID, true
+					break
+				}
+			}
+		}
+		if !ok {
+			ix.unmatched = append(ix.unmatched, item)
+			continue
+		}
+		ix.videos[id] = append(ix.videos[id], item)
+	}
+	return ix
+}
+
+// missing returns the given episodes without any video in the playlist.
+func (ix playlistIndex) missing(eps []podcast.Episode) []podcast.Episode {
+	var res []podcast.Episode
+	for _, ep := range eps {
+		if len(ix.videos[ep.ID]) == 0 {
+			res = append(res, ep)
+		}
+	}
+	return res
+}
+
+// firstVideos returns the IDs of the first playlist item of each of the given
+// episodes that has one, in the order of the episodes.
+func (ix playlistIndex) firstVideos(eps []podcast.Episode) []string {
+	var ids []string
+	for _, ep := range eps {
+		if v := ix.videos[ep.ID]; len(v) > 0 {
+			ids = append(ids, v[0].ID)
+		}
+	}
+	return ids
+}
+
+// sortEpisodes returns a copy of the given episodes sorted by number or date,
+// oldest first, or newest first if the key is preceded by "-".
+func sortEpisodes(eps []podcast.Episode, key string) ([]podcast.Episode, error) {
+	desc := strings.HasPrefix(key, "-")
+	var less func(a, b podcast.Episode) bool
+	switch strings.TrimPrefix(key, "-") {
+	case "number":
+		less = func(a, b podcast.Episode) bool { return a.Number < b.Number }
+	case "date":
+		less = func(a, b podcast.Episode) bool { return a.Published.Before(b.Published) }
+	default:
+		return nil, fmt.Errorf("unknown sort key %q; use number, date, -number, or -date", key)
+	}
+
+	sorted := append([]podcast.Episode(nil), eps...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if desc {
+			return less(sorted[j], sorted[i])
+		}
+		return less(sorted[i], sorted[j])
+	})
+	return sorted, nil
+}
+
+// playlistRecord returns the record of an episode of the given show published
+// as the given playlist item.
+func playlistRecord(s show, ep podcast.Episode, item youtube.PlaylistItem) state.Record {
+	uploaded := item.VideoPublishedAt
+	if uploaded.IsZero() {
+		uploaded = item.PublishedAt
+	}
+	return state.Record{
+		EpisodeID: ep.ID,
+		Playlist:  s.playlist,
+		VideoID:   item.VideoID,
+		Uploaded:  uploaded,
+		Status:    state.StatusPublished,
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+	"github.com/campoy/podcast-to-youtube/youtube"
+)
+
+func TestIsEpisode(t *testing.T) {
+	ep := podcast.Episode{ID: "guid-1", Number: 1}
+	tests := []struct {
+		title, desc string
+		want        bool
+	}{
+		{"Hello: GCPPodcast 1", "", true},
+		{"Hello: GCPPodcast 11", "", false},
+		{"1", "", true},
+		{"Hello: GCPPodcast 2", "", false},
+		{"Edited title", "Original post: x\n\nEpisode ID: guid-1", true},
+		{"Hello: GCPPodcast 1", "Episode ID: guid-12", false},
+		{"Hello", "Episode ID: guid-1\nmore", true},
+	}
+	for _, tt := range tests {
+		if got := isEpisode(tt.title, tt.desc, ep); got != tt.want {
+			t.Errorf("isEpisode(%q, %q) = %v; expected %v", tt.title, tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestIndexPlaylist(t *testing.T) {
+	eps := []podcast.Episode{{ID: "a", Number: 1}, {ID: "b", Number: 2}, {ID: "c", Number: 3}}
+	items := []youtube.PlaylistItem{
+		{VideoID: "v1", Title: "Old upload 1"},
+		{VideoID: "v2", Title: "Renamed", Description: "Episode ID: b"},
+		{VideoID: "v3", Title: "Again 2", Description: "Episode ID: b\nmore"},
+		{VideoID: "v4", Title: "Trailer"},
+		{VideoID: "v5", Title: "Other show 3", Description: "Episode ID: z"},
+	}
+	ix := indexPlaylist(items, eps)
+
+	videos := func(id string) string {
+		var s string
+		for _, v := range ix.videos[id] {
+			s += v.VideoID
+		}
+		return s
+	}
+	if got := videos("a"); got != "v1" {
+		t.Errorf("expected video v1 for episode a; got %q", got)
+	}
+	if got := videos("b"); got != "v2v3" {
+		t.Errorf("expected videos v2 and v3 for episode b; got %q", got)
+	}
+	if len(ix.unmatched) != 2 || ix.unmatched[0].VideoID != "v4" || ix.unmatched[1].VideoID != "v5" {
+		t.Errorf("expected videos v4 and v5 unmatched; got %+v", ix.unmatched)
+	}
+	if missing := ix.missing(eps); len(missing) != 1 || missing[0].ID != "c" {
+		t.Errorf("expected episode c missing; got %+v", missing)
+	}
+}
+
+func TestSortEpisodes(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	eps := []podcast.Episode{{ID: "b", Number: 2, Published: day(1)}, {ID: "c", Number: 3, Published: day(3)}, {ID: "a", Number: 1, Published: day(2)}}
+	tests := map[string]string{"number": "abc", "-number": "cba", "date": "bac", "-date": "cab"}
+	for key, want := range tests {
+		sorted, err := sortEpisodes(eps, key)
+		if err != nil {
+			t.Fatalf("could not sort by %s: %v", key, err)
+		}
+		got := ""
+		for _, ep := range sorted {
+			got += ep.ID
+		}
+		if got != want {
+			t.Errorf("sorting by %s gave %s; expected %s", key, got, want)
+		}
+	}
+	if _, err := sortEpisodes(eps, "title"); err == nil {
+		t.Errorf("expected error sorting by title")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// parseAtom decodes the episodes in an Atom feed. The links of relation
+// "enclosure" in each entry are the enclosures of the episode.
+func parseAtom(data []byte, lenient bool) (*feedPage, error) {
+	var feed struct {
+		XMLName xml.Name   `xml:"http://www.w3.org/2005/Atom feed"`
+		Lang    string     `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+		Title   string     `xml:"title"`
+		Link    []atomLink `xml:"link"`
+		Icon    string     `xml:"icon"`
+		Logo    string     `xml:"logo"`
+		Author  []struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Entry []atomEntry `xml:"entry"`
+	}
+
+	diags, err := decodeXMLItems(data, &feed, "entry", "feed", lenient)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Podcast{
+		Title:    strings.TrimSpace(feed.Title),
+		Image:    feed.Logo,
+		Language: feed.Lang,
+		Link:     alternateLink(feed.Link),
+	}
+	if p.Image == "" {
+		p.Image = feed.Icon
+	}
+	if len(feed.Author) > 0 {
+		p.Author = strings.TrimSpace(feed.Author[0].Name)
+	}
+
+	page := feedPage{Next: nextLink(feed.Link), Diagnostics: diags}
+	for n, e := range feed.Entry {
+		ep, err := e.episode()
+		if err != nil {
+			page.Diagnostics = append(page.Diagnostics, Diagnostic{Item: itemPosition(n+1, diags), Title: ep.Title, Err: err})
+		}
+		ep.Podcast = p
+		page.Episodes = append(page.Episodes, ep)
+	}
+	return &page, nil
+}
+
+// atomLink is the XML representation of a link in an Atom feed.
+type atomLink struct {
+	Rel    string `xml:"rel,attr"`
+	Href   string `xml:"href,attr"`
+	Type   string `xml:"type,attr"`
+	Length int64  `xml:"length,attr"`
+}
+
+// nextLink returns the target of the link to the next page of a paged feed,
+// or to the previous archive of an archived feed, in the given list.
+// See RFC 5005 for the meaning of each relation.
+func nextLink(links []atomLink) string {
+	for _, rel := range []string{"next", "prev-archive"} {
+		for _, l := range links {
+			if l.Rel == rel {
+				return l.Href
+			}
+		}
+	}
+	return ""
+}
+
+// alternateLink returns the target of the alternate link in the given list.
+func alternateLink(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// atomEntry is the XML representation of an entry in an Atom feed.
+type atomEntry struct {
+	Title     string     `xml:"title"`
+	ID        string     `xml:"id"`
+	Link      []atomLink `xml:"link"`
+	Summary   string     `xml:"summary"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Content   string     `xml:"content"`
+	Category  []struct {
+		Term string `xml:"term,attr"`
+	} `xml:"category"`
+}
+
+// episode converts the entry into an Episode. The alternate link is used
+// as the episode link, which is empty if the entry has none. The error, if
+// not nil, describes a problem with the publication date.
+func (e atomEntry) episode() (Episode, error) {
+	published, dateErr := parseDate(e.Published)
+	ep := Episode{
+		ID:        strings.TrimSpace(e.ID),
+		Title:     strings.TrimSpace(e.Title),
+		Link:      alternateLink(e.Link),
+		Desc:      e.Summary,
+		Published: published,
+		Type:      "full",
+	}
+	if ep.Published.IsZero() {
+		updated, err := parseDate(e.Updated)
+		if !updated.IsZero() || dateErr == nil {
+			ep.Published, dateErr = updated, err
+		}
+	}
+	if ep.Desc == "" {
+		ep.Desc = e.Content
+	}
+	for _, l := range e.Link {
+		if l.Rel == "enclosure" {
+			ep.Enclosures = append(ep.Enclosures, newEnclosure(l.Href, l.Type, l.Length, 0))
+		}
+	}
+	for _, c := range e.Category {
+		ep.Tags = append(ep.Tags, c.Term)
+	}
+	return ep, dateErr
+}
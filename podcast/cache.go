@@ -0,0 +1,113 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// cacheVersion is the version of the cache entry format. Entries with any
+// other version are ignored. It must be increased whenever the fields of
+// feedPage or Episode change.
+const cacheVersion = 2
+
+// A cacheEntry is the cached state of a feed fetched over HTTP.
+type cacheEntry struct {
+	Version      int
+	URL          string
+	ETag         string
+	LastModified string
+	Page         *feedPage
+}
+
+// cachePath returns the path of the cache file for the given feed URL.
+func (f *Fetcher) cachePath(rawurl string) string {
+	return filepath.Join(f.CacheDir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(rawurl))))
+}
+
+// loadCache returns the cached entry for the given URL, or nil if caching
+// is disabled or the feed has not been cached yet. Since the cache can always
+// be rebuilt, entries that can not be read are reported and removed.
+func (f *Fetcher) loadCache(rawurl string) *cacheEntry {
+	if f.CacheDir == "" {
+		return nil
+	}
+
+	path := f.cachePath(rawurl)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		f.cacheError(rawurl, "could not read %s: %v", path, err)
+		return nil
+	}
+
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		f.cacheError(rawurl, "could not decode %s: %v", path, err)
+		if err := os.Remove(path); err != nil {
+			f.cacheError(rawurl, "could not remove %s: %v", path, err)
+		}
+		return nil
+	}
+	if e.Version != cacheVersion || e.URL != rawurl || e.Page == nil {
+		return nil
+	}
+	return &e
+}
+
+// saveCache stores the given entry in the cache directory. Entries without
+// validators are not stored, since they could never be used. Failures are
+// reported, since the feed can be fetched without the cache.
+func (f *Fetcher) saveCache(e *cacheEntry) {
+	if f.CacheDir == "" || (e.ETag == "" && e.LastModified == "") {
+		return
+	}
+
+	if err := os.MkdirAll(f.CacheDir, 0755); err != nil {
+		f.cacheError(e.URL, "could not create cache directory: %v", err)
+		return
+	}
+
+	e.Version = cacheVersion
+	data, err := json.Marshal(e)
+	if err != nil {
+		f.cacheError(e.URL, "could not encode cache entry: %v", err)
+		return
+	}
+
+	// We write to a temporary file first so concurrent runs never see a
+	// partially written entry.
+	path := f.cachePath(e.URL)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		f.cacheError(e.URL, "could not write %s: %v", tmp, err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		f.cacheError(e.URL, "could not write %s: %v", path, err)
+		os.Remove(tmp)
+	}
+}
+
+// cacheError reports a problem with the cache entry of the given URL.
+func (f *Fetcher) cacheError(rawurl, format string, args ...interface{}) {
+	f.report(rawurl, []Diagnostic{{Err: fmt.Errorf("%w: %s", ErrCache, fmt.Sprintf(format, args...))}})
+}
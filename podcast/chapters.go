@@ -0,0 +1,105 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// A Chapter is a section of an episode, as described by Podcasting 2.0
+// JSON chapters. See https://github.com/Podcastindex-org/podcast-namespace.
+type Chapter struct {
+	Start time.Duration
+	End   time.Duration // Zero if unknown.
+	Title string
+	Image string // URL of the chapter artwork, if any.
+	URL   string // URL related to the chapter, if any.
+}
+
+// A Person is someone involved in an episode, such as a host or a guest.
+type Person struct {
+	Name  string
+	Role  string // Such as "host" or "guest"; lowercase.
+	Group string // Such as "cast" or "writing"; lowercase.
+	Image string // URL of a picture of the person, if any.
+	Href  string // URL of a page about the person, if any.
+}
+
+// FetchChapters fetches and parses the chapters referenced by ChaptersURL,
+// storing them in the Chapters of the given episode.
+func (f *Fetcher) FetchChapters(ctx context.Context, ep *Episode) error {
+	if ep.ChaptersURL == "" {
+		return nil
+	}
+	data, err := f.get(ctx, ep.ChaptersURL)
+	if err != nil {
+		return err
+	}
+	chs, err := parseChapters(data)
+	if err != nil {
+		return fmt.Errorf("could not parse chapters in %s: %w", ep.ChaptersURL, err)
+	}
+	ep.Chapters = chs
+	return nil
+}
+
+// parseChapters parses a Podcasting 2.0 JSON chapters document.
+// The end of chapters without one is the start of the following chapter.
+func parseChapters(data []byte) ([]Chapter, error) {
+	var doc struct {
+		Version  string `json:"version"`
+		Chapters []struct {
+			StartTime float64 `json:"startTime"`
+			EndTime   float64 `json:"endTime"`
+			Title     string  `json:"title"`
+			Img       string  `json:"img"`
+			URL       string  `json:"url"`
+			TOC       *bool   `json:"toc"`
+		} `json:"chapters"`
+	}
+	if err := decodeJSON(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var chs []Chapter
+	for _, c := range doc.Chapters {
+		// Chapters not in the table of contents are only meant to change
+		// the artwork or link displayed while playing.
+		if c.TOC != nil && !*c.TOC {
+			continue
+		}
+		chs = append(chs, Chapter{
+			Start: seconds(c.StartTime),
+			End:   seconds(c.EndTime),
+			Title: c.Title,
+			Image: c.Img,
+			URL:   c.URL,
+		})
+	}
+	sort.SliceStable(chs, func(i, j int) bool { return chs[i].Start < chs[j].Start })
+	for i := range chs {
+		if chs[i].End == 0 && i+1 < len(chs) {
+			chs[i].End = chs[i+1].Start
+		}
+	}
+	return chs, nil
+}
+
+// seconds converts a number of seconds into a duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
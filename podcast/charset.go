@@ -0,0 +1,109 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// cp1252 maps the bytes from 0x80 to 0x9F in Windows-1252 to their runes.
+// The remaining bytes have the same value as the runes they encode.
+var cp1252 = [32]rune{
+	'€', '�', '‚', 'ƒ', '„', '…', '†', '‡',
+	'ˆ', '‰', 'Š', '‹', 'Œ', '�', 'Ž', '�',
+	'�', '‘', '’', '“', '”', '•', '–', '—',
+	'˜', '™', 'š', '›', 'œ', '�', 'ž', 'Ÿ',
+}
+
+// charsetReader converts the input in the given charset to UTF-8.
+// It is used as the CharsetReader of the XML decoders in this package.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "windows-1252", "cp1252", "x-cp1252",
+		// ISO-8859-1 is decoded as Windows-1252, like browsers do, since
+		// feeds declaring the former are very often encoded in the latter.
+		"iso-8859-1", "iso8859-1", "iso_8859-1", "latin1", "latin-1", "l1":
+		data, err := ioutil.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(decodeCP1252(data)), nil
+	default:
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	}
+}
+
+// decodeCP1252 converts the given Windows-1252 text to UTF-8.
+func decodeCP1252(data []byte) []byte {
+	buf := make([]byte, 0, len(data))
+	for _, b := range data {
+		r := rune(b)
+		if b >= 0x80 && b < 0xA0 {
+			r = cp1252[b-0x80]
+		}
+		buf = append(buf, string(r)...)
+	}
+	return buf
+}
+
+// hasEncodingDecl reports whether the XML document in data starts with an
+// XML declaration specifying its encoding.
+func hasEncodingDecl(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("<?xml")) {
+		return false
+	}
+	end := bytes.Index(data, []byte("?>"))
+	return end >= 0 && bytes.Contains(data[:end], []byte("encoding"))
+}
+
+var encodingRE = regexp.MustCompile(`^(\s*<\?xml[^>]*?encoding\s*=\s*["'])([^"']*)(["'])`)
+
+// transcode converts XML documents declaring a Windows-1252 or ISO-8859-1
+// encoding to UTF-8, changing the declaration accordingly. Decoding the
+// result, instead of using charsetReader, keeps the offsets reported by the
+// decoder valid in the document that is decoded; their lines and characters
+// are the same as in the original.
+func transcode(data []byte) []byte {
+	m := encodingRE.FindSubmatchIndex(data)
+	if m == nil {
+		return data
+	}
+	switch strings.ToLower(strings.TrimSpace(string(data[m[4]:m[5]]))) {
+	case "windows-1252", "cp1252", "x-cp1252",
+		"iso-8859-1", "iso8859-1", "iso_8859-1", "latin1", "latin-1", "l1":
+	default:
+		return data
+	}
+	out := append([]byte(nil), data[:m[4]]...)
+	out = append(out, "UTF-8"...)
+	return append(out, decodeCP1252(data[m[5]:])...)
+}
+
+// fixEncoding converts documents that are not valid UTF-8 and do not declare
+// their encoding from Windows-1252, which is the most common mistake.
+func fixEncoding(data []byte) []byte {
+	if utf8.Valid(data) || hasEncodingDecl(data) {
+		return data
+	}
+	return decodeCP1252(data)
+}
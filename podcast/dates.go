@@ -0,0 +1,203 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// isoLayouts are the layouts of the dates in Atom feeds and JSON Feeds, and
+// of the RFC 3339 dates that some RSS feeds use instead of RFC 822 ones.
+var isoLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// rfc822Layouts are the layouts of RFC 822 dates, once the day of the week
+// and the time zone have been removed. They cover the variants found in
+// real feeds: with or without seconds, with two or four digit years, with
+// abbreviated or full month names, and with 24 or 12 hour clocks.
+var rfc822Layouts = []string{
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"2 January 2006 15:04:05",
+	"2 January 2006 15:04",
+	"2 Jan 06 15:04:05",
+	"2 Jan 06 15:04",
+	"2 Jan 2006 3:04:05 PM",
+	"2 Jan 2006 3:04 PM",
+	"2 January 2006 3:04:05 PM",
+	"2 January 2006 3:04 PM",
+	"2 Jan 06 3:04 PM",
+	"2 Jan 2006",
+	"2 January 2006",
+}
+
+// zoneOffsets maps the time zone names allowed by RFC 822, and a few more
+// that are common in feeds, to their offset from UTC in hours.
+var zoneOffsets = map[string]int{
+	"UT": 0, "UTC": 0, "GMT": 0, "Z": 0,
+	"EST": -5, "EDT": -4,
+	"CST": -6, "CDT": -5,
+	"MST": -7, "MDT": -6,
+	"PST": -8, "PDT": -7,
+	"CET": 1, "CEST": 2,
+	"BST": 1, "IST": 5,
+	"EET": 2, "EEST": 3,
+	"BRT": -3, "JST": 9,
+	"AEST": 10, "AEDT": 11,
+}
+
+// parseDate parses a publication date in any of the formats used by the
+// supported feed formats, tolerating the broken RFC 822 dates found in many
+// RSS feeds. Dates without a time zone, or with an unknown one, are
+// considered to be in UTC.
+// It returns the zero time if the date is invalid. The error, which wraps
+// ErrInvalidDate, is also set when an unknown time zone was ignored.
+func parseDate(s string) (time.Time, error) {
+	orig := s
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range isoLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	// The day of the week is optional, and often wrong, so we ignore it.
+	if i := strings.Index(s, ","); i >= 0 {
+		s = strings.TrimSpace(s[i+1:])
+	} else if f := strings.Fields(s); len(f) > 0 && isWeekday(f[0]) {
+		s = strings.Join(f[1:], " ")
+	}
+
+	// Month names such as "Sept" or "Aug.", and lower case "am" and "pm",
+	// are rewritten as Go expects them.
+	fs := strings.Fields(s)
+	for i, f := range fs {
+		switch u := strings.ToUpper(strings.TrimSuffix(f, ".")); {
+		case u == "SEPT":
+			fs[i] = "Sep"
+		case u == "AM" || u == "PM":
+			fs[i] = u
+		case len(u) >= 3 && isMonth(u):
+			fs[i] = strings.TrimSuffix(f, ".")
+		}
+	}
+	s = strings.Join(fs, " ")
+
+	// Unknown time zone names are dropped rather than losing the whole date.
+	var warning error
+	loc := time.UTC
+	if i := strings.LastIndex(s, " "); i >= 0 {
+		zone := s[i+1:]
+		if loc1, ok := parseZone(zone); ok {
+			s, loc = s[:i], loc1
+		} else if isZoneName(zone) && zone != "AM" && zone != "PM" {
+			warning = fmt.Errorf("%w %q: unknown time zone %q, using UTC", ErrInvalidDate, orig, zone)
+			s = s[:i]
+		}
+	}
+
+	for _, layout := range rfc822Layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, warning
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w %q", ErrInvalidDate, orig)
+}
+
+// isMonth reports whether s, in upper case, is the name or the abbreviated
+// name of a month.
+func isMonth(s string) bool {
+	for m := time.January; m <= time.December; m++ {
+		if name := strings.ToUpper(m.String()); s == name || s == name[:3] {
+			return true
+		}
+	}
+	return false
+}
+
+// isWeekday reports whether s is the name, or the abbreviated name, of a day
+// of the week.
+func isWeekday(s string) bool {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(s, d.String()) || strings.EqualFold(s, d.String()[:3]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isZoneName reports whether s looks like the name of a time zone, possibly
+// followed by an offset, such as "NZST" or "XYZ+3".
+func isZoneName(s string) bool {
+	return s != "" && (s[0] >= 'A' && s[0] <= 'Z' || s[0] >= 'a' && s[0] <= 'z')
+}
+
+// parseZone parses a time zone given as a name such as "PST", as a numeric
+// offset such as "-0800" or "+02:00", or as a combination of both such as
+// "GMT+2".
+func parseZone(s string) (*time.Location, bool) {
+	name, offset := s, ""
+	if i := strings.IndexAny(s, "+-"); i >= 0 {
+		name, offset = s[:i], s[i:]
+	}
+
+	hours, ok := zoneOffsets[strings.ToUpper(name)]
+	if name != "" && !ok {
+		return nil, false
+	}
+	secs := hours * 3600
+	if offset != "" {
+		off, ok := parseOffset(offset)
+		if !ok {
+			return nil, false
+		}
+		secs += off
+	}
+	return time.FixedZone(s, secs), true
+}
+
+// parseOffset parses a numeric time zone offset in any of the forms
+// "+h", "+hh", "+hhmm", or "+hh:mm", returning it in seconds.
+func parseOffset(s string) (int, bool) {
+	sign := 1
+	if s[0] == '-' {
+		sign = -1
+	}
+	digits := strings.Replace(s[1:], ":", "", 1)
+	if len(digits) == 0 || len(digits) > 4 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0, false
+	}
+	h, m := n, 0
+	if len(digits) > 2 {
+		h, m = n/100, n%100
+	}
+	if h > 14 || m > 59 {
+		return 0, false
+	}
+	return sign * (h*3600 + m*60), true
+}
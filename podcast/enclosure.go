@@ -0,0 +1,114 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+import (
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// An Enclosure is a media file attached to an episode.
+type Enclosure struct {
+	URL     string
+	Type    string // MIME type, such as "audio/mpeg".
+	Length  int64  // Size in bytes; zero if unknown.
+	Bitrate int    // In bits per second; zero if unknown.
+}
+
+// newEnclosure returns an enclosure with the given attributes. The type is
+// guessed from the URL extension if missing.
+func newEnclosure(rawurl, typ string, length int64, bitrate int) Enclosure {
+	rawurl, typ = strings.TrimSpace(rawurl), strings.TrimSpace(typ)
+	if typ == "" {
+		if u, err := url.Parse(rawurl); err == nil {
+			typ = mime.TypeByExtension(path.Ext(u.Path))
+		}
+	}
+	if i := strings.Index(typ, ";"); i >= 0 {
+		typ = strings.TrimSpace(typ[:i])
+	}
+	return Enclosure{URL: rawurl, Type: strings.ToLower(typ), Length: length, Bitrate: bitrate}
+}
+
+// An EnclosurePolicy selects the media of an episode among its enclosures.
+type EnclosurePolicy struct {
+	// Types lists the preferred MIME types, most preferred first. A type
+	// ending in "/*", such as "audio/*", matches all the types with that
+	// prefix. Enclosures matching none of the types are only selected when
+	// no other enclosure is available. If empty, audio enclosures are
+	// preferred, followed by video ones.
+	Types []string
+	// LowestBitrate selects the enclosure with the lowest, rather than the
+	// highest, bitrate among those of the most preferred type.
+	LowestBitrate bool
+}
+
+// Select returns the enclosure best matching the policy, or false if there
+// are no enclosures. The duration of the episode, if not zero, is used to
+// estimate the bitrate of enclosures that do not provide it.
+func (p EnclosurePolicy) Select(encs []Enclosure, d time.Duration) (Enclosure, bool) {
+	best := -1
+	for i, e := range encs {
+		if e.URL == "" {
+			continue
+		}
+		if best < 0 || p.better(e, encs[best], d) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return Enclosure{}, false
+	}
+	return encs[best], true
+}
+
+// better reports whether a is preferred over b.
+func (p EnclosurePolicy) better(a, b Enclosure, d time.Duration) bool {
+	if ra, rb := p.rank(a.Type), p.rank(b.Type); ra != rb {
+		return ra < rb
+	}
+	ba, bb := bitrate(a, d), bitrate(b, d)
+	if p.LowestBitrate {
+		return ba < bb
+	}
+	return ba > bb
+}
+
+// rank returns the position of the first of the preferred types matching
+// the given one, or the number of preferred types if none matches.
+func (p EnclosurePolicy) rank(typ string) int {
+	types := p.Types
+	if len(types) == 0 {
+		types = []string{"audio/*", "video/*"}
+	}
+	for i, t := range types {
+		t = strings.ToLower(t)
+		if t == typ || strings.HasSuffix(t, "/*") && strings.HasPrefix(typ, t[:len(t)-1]) {
+			return i
+		}
+	}
+	return len(types)
+}
+
+// bitrate returns the bitrate of the enclosure in bits per second, estimated
+// from its length and the given duration if needed.
+func bitrate(e Enclosure, d time.Duration) int {
+	if e.Bitrate > 0 || e.Length <= 0 || d <= 0 {
+		return e.Bitrate
+	}
+	return int(float64(e.Length*8) / d.Seconds())
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Errors returned when a feed is well formed but contains no episodes.
+var (
+	ErrNoChannel = errors.New("feed has no channel")
+	ErrNoItems   = errors.New("feed has no items")
+)
+
+// ErrDuplicateID is wrapped by the diagnostics reported for the episodes
+// skipped because another episode in the feed has the same ID.
+var ErrDuplicateID = errors.New("duplicate episode ID")
+
+// ErrInvalidDate is wrapped by the diagnostics reported for the episodes whose
+// publication date can not be parsed, and which are considered to have none,
+// or whose time zone is unknown, and which are considered to be in UTC.
+var ErrInvalidDate = errors.New("invalid publication date")
+
+// ErrCache is wrapped by the diagnostics reported when the feed cache can not
+// be read or written. Fetching continues as if caching was disabled.
+var ErrCache = errors.New("could not use feed cache")
+
+// A DecodeError is returned when a feed is not a valid XML or JSON document.
+type DecodeError struct {
+	Line   int // Line of the feed where decoding failed, starting at 1.
+	Column int // Column of the feed where decoding failed, in characters, starting at 1.
+	Err    error
+
+	offset int64 // Offset in bytes where decoding failed.
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("could not decode feed at line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// newDecodeError returns a DecodeError for the given error that happened
+// after decoding offset bytes of data.
+func newDecodeError(data []byte, offset int64, err error) *DecodeError {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	prefix := data[:offset]
+	line := bytes.Count(prefix, []byte("\n")) + 1
+	col := utf8.RuneCount(prefix[bytes.LastIndexByte(prefix, '\n')+1:]) + 1
+	return &DecodeError{Line: line, Column: col, Err: err, offset: offset}
+}
+
+// newXMLDecoder returns a decoder for the XML document in data, which
+// understands HTML entities and the most common non UTF-8 charsets.
+// Decoders are always strict, since the lenient decoder of encoding/xml
+// silently nests the elements following a mismatched tag in it.
+func newXMLDecoder(data []byte) *xml.Decoder {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = charsetReader
+	dec.Entity = xml.HTMLEntity
+	return dec
+}
+
+// decodeXML decodes the XML document in data into v.
+func decodeXML(data []byte, v interface{}) error {
+	dec := newXMLDecoder(data)
+	if err := dec.Decode(v); err != nil {
+		return newDecodeError(data, dec.InputOffset(), err)
+	}
+	return nil
+}
+
+// decodeJSON decodes the JSON document in data into v.
+func decodeJSON(data []byte, v interface{}) error {
+	err := json.Unmarshal(data, v)
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *json.SyntaxError:
+		return newDecodeError(data, e.Offset, err)
+	case *json.UnmarshalTypeError:
+		return newDecodeError(data, e.Offset, err)
+	default:
+		return newDecodeError(data, 0, err)
+	}
+}
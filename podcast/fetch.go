@@ -0,0 +1,330 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// A Fetcher fetches podcast feeds from the network, the local file system,
+// or the standard input. The zero value is ready to use.
+type Fetcher struct {
+	// Client is used for HTTP requests. If nil, http.DefaultClient is used.
+	// Set it to configure timeouts, proxies, or custom certificate authorities.
+	Client *http.Client
+	// Stdin is read when the feed source is "-". If nil, os.Stdin is used.
+	Stdin io.Reader
+	// CacheDir is the directory where HTTP feeds are cached, together with
+	// their ETag and Last-Modified headers, so later fetches can be sent as
+	// conditional requests. Caching is disabled if empty.
+	CacheDir string
+	// TitleNumber finds the number of episodes that do not provide one in
+	// the feed. Its first subexpression, or the whole match if it has none,
+	// must be the episode number. If nil, DefaultTitleNumber is used.
+	TitleNumber *regexp.Regexp
+	// Order is the order of the returned episodes. If empty, episodes are
+	// sorted by number.
+	Order Order
+	// MaxPages is the maximum number of documents fetched for feeds split in
+	// pages or archives (RFC 5005), including the first one. Episodes in all
+	// the pages are merged, and only the first page is fetched if MaxPages
+	// is zero or one.
+	MaxPages int
+	// Lenient enables decoding malformed XML feeds, such as those containing
+	// undeclared Windows-1252 text or items with unclosed HTML tags. Items
+	// that can not be decoded are skipped and reported to Report, if not nil.
+	Lenient bool
+	Report  func(Diagnostic)
+	// Enclosures selects the Media of each episode among its enclosures.
+	Enclosures EnclosurePolicy
+}
+
+// Fetch fetches the list of episodes in the feed at the given source, which
+// can be an http or https URL, a file URL, a path to a local file, or "-" to
+// read the feed from the standard input.
+func (f *Fetcher) Fetch(ctx context.Context, source string) ([]Episode, error) {
+	eps, err := f.fetchPages(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	return f.finish(eps)
+}
+
+// fetchPages fetches the episodes in up to f.MaxPages pages of the feed at
+// the given source, starting with the newest one. Episodes whose ID was
+// already found are skipped, and reported to f.Report if they are repeated
+// within a page, since overlapping pages are to be expected.
+func (f *Fetcher) fetchPages(ctx context.Context, source string) ([]Episode, error) {
+	var eps []Episode
+	seen := make(map[string]bool)
+	visited := make(map[string]bool)
+	for n := 0; source != "" && !visited[source]; n++ {
+		if n > 0 && n >= f.MaxPages {
+			break
+		}
+		visited[source] = true
+
+		p, err := f.fetch(ctx, source)
+		if n > 0 && errors.Is(err, ErrNoItems) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		f.report(source, p.Diagnostics)
+		var dups []Diagnostic
+		inPage := make(map[string]bool)
+		for _, ep := range p.Episodes {
+			dup := inPage[ep.ID]
+			inPage[ep.ID] = true
+			if dup {
+				dups = append(dups, Diagnostic{
+					Title:   ep.Title,
+					Skipped: true,
+					Err:     fmt.Errorf("%w %q", ErrDuplicateID, ep.ID),
+				})
+			}
+			if seen[ep.ID] {
+				continue
+			}
+			seen[ep.ID] = true
+			eps = append(eps, ep)
+		}
+		f.report(source, dups)
+		source = resolve(source, p.Next)
+	}
+	return eps, nil
+}
+
+// resolve resolves the possibly relative URL ref against base.
+func resolve(base, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
+
+// fetch fetches a single document of the feed at the given source.
+func (f *Fetcher) fetch(ctx context.Context, source string) (*feedPage, error) {
+	if source == "-" {
+		return f.parse(f.stdin(), "", source)
+	}
+
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", source, err)
+	}
+
+	var path string
+	switch u.Scheme {
+	case "http", "https":
+		return f.fetchHTTP(ctx, source)
+	case "file":
+		path = filepath.FromSlash(u.Path)
+	case "":
+		path = source
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q in %s", u.Scheme, source)
+	}
+
+	r, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %v", path, err)
+	}
+	defer r.Close()
+	return f.parse(r, "", source)
+}
+
+// fetchHTTP fetches the feed document at the given URL. If caching is
+// enabled the request is conditional, and the cached document is returned
+// when the feed has not been modified.
+func (f *Fetcher) fetchHTTP(ctx context.Context, rawurl string) (*feedPage, error) {
+	req, err := http.NewRequest("GET", rawurl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request for %s: %v", rawurl, err)
+	}
+
+	cached := f.loadCache(rawurl)
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	res, err := f.client().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("could not get %s: %v", rawurl, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		return cached.Page, nil
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, fmt.Errorf("could not get %s: %s", rawurl, res.Status)
+	}
+
+	p, err := f.parse(res.Body, res.Header.Get("Content-Type"), rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	f.saveCache(&cacheEntry{
+		URL:          rawurl,
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		Page:         p,
+	})
+	return p, nil
+}
+
+// Parse decodes the episodes in the feed read from r, sorted as specified by
+// f.Order. The content type is optional, and it is only used to detect the
+// feed format. Feeds without episodes result in ErrNoChannel or ErrNoItems,
+// and malformed feeds in a *DecodeError.
+// Links to other pages of the feed are not followed.
+func (f *Fetcher) Parse(r io.Reader, contentType string) ([]Episode, error) {
+	p, err := f.decode(r, contentType)
+	if err != nil {
+		return nil, err
+	}
+	f.report("", p.Diagnostics)
+	return f.finish(p.Episodes)
+}
+
+// decode decodes the feed document read from r.
+func (f *Fetcher) decode(r io.Reader, contentType string) (*feedPage, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read feed: %v", err)
+	}
+	return parseFeed(contentType, data, f.Lenient)
+}
+
+// report reports the given diagnostics, found in the given source.
+func (f *Fetcher) report(source string, diags []Diagnostic) {
+	if f.Report == nil {
+		return
+	}
+	for _, d := range diags {
+		d.Source = source
+		f.Report(d)
+	}
+}
+
+// parse decodes the feed read from r, using source only for error messages.
+// The returned errors wrap the ones returned by f.decode, so they can be
+// inspected with errors.Is and errors.As.
+func (f *Fetcher) parse(r io.Reader, contentType, source string) (*feedPage, error) {
+	p, err := f.decode(r, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", source, err)
+	}
+	return p, nil
+}
+
+// finish selects the media of the episodes, given in feed order, numbers
+// them, and sorts them.
+func (f *Fetcher) finish(eps []Episode) ([]Episode, error) {
+	for i, ep := range eps {
+		if e, ok := f.Enclosures.Select(ep.Enclosures, ep.Duration); ok {
+			eps[i].Media = e.URL
+		}
+	}
+
+	title := f.TitleNumber
+	if title == nil {
+		title = DefaultTitleNumber
+	}
+	inferNumbers(eps, title)
+
+	if err := f.sort(eps); err != nil {
+		return nil, err
+	}
+	return eps, nil
+}
+
+// sort sorts the episodes, given in feed order, as specified by f.Order.
+func (f *Fetcher) sort(eps []Episode) error {
+	switch f.Order {
+	case "", OrderNumber:
+		sort.SliceStable(eps, func(i, j int) bool { return eps[i].Number < eps[j].Number })
+	case OrderDate:
+		reverse(eps)
+		sort.SliceStable(eps, func(i, j int) bool { return eps[i].Published.Before(eps[j].Published) })
+	case OrderFeed:
+		reverse(eps)
+	default:
+		return fmt.Errorf("unknown episode order %q", f.Order)
+	}
+	return nil
+}
+
+func (f *Fetcher) stdin() io.Reader {
+	if f.Stdin != nil {
+		return f.Stdin
+	}
+	return os.Stdin
+}
+
+// get fetches the document at the given URL, which is referenced by a feed.
+func (f *Fetcher) get(ctx context.Context, rawurl string) ([]byte, error) {
+	req, err := http.NewRequest("GET", rawurl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request for %s: %v", rawurl, err)
+	}
+
+	res, err := f.client().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("could not get %s: %v", rawurl, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, fmt.Errorf("could not get %s: %s", rawurl, res.Status)
+	}
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %v", rawurl, err)
+	}
+	return data, nil
+}
+
+func (f *Fetcher) client() *http.Client {
+	if f.Client != nil {
+		return f.Client
+	}
+	return http.DefaultClient
+}
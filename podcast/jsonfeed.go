@@ -0,0 +1,120 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseJSONFeed decodes the episodes in a JSON Feed document.
+// See https://jsonfeed.org/version/1.1 for the specification.
+func parseJSONFeed(data []byte) (*feedPage, error) {
+	var feed struct {
+		Version     string       `json:"version"`
+		Title       string       `json:"title"`
+		HomePageURL string       `json:"home_page_url"`
+		NextURL     string       `json:"next_url"`
+		Icon        string       `json:"icon"`
+		Language    string       `json:"language"`
+		Authors     []jsonAuthor `json:"authors"`
+		Author      *jsonAuthor  `json:"author"` // Deprecated in version 1.1.
+		Items       []jsonItem   `json:"items"`
+	}
+
+	if err := decodeJSON(data, &feed); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(feed.Version, "https://jsonfeed.org/version/") {
+		return nil, fmt.Errorf("unknown JSON feed version %q", feed.Version)
+	}
+
+	p := &Podcast{
+		Title:    feed.Title,
+		Image:    feed.Icon,
+		Language: feed.Language,
+		Link:     feed.HomePageURL,
+	}
+	if len(feed.Authors) > 0 {
+		p.Author = feed.Authors[0].Name
+	} else if feed.Author != nil {
+		p.Author = feed.Author.Name
+	}
+
+	page := feedPage{Next: feed.NextURL}
+	for n, i := range feed.Items {
+		ep, err := i.episode()
+		if err != nil {
+			page.Diagnostics = append(page.Diagnostics, Diagnostic{Item: n + 1, Title: ep.Title, Err: err})
+		}
+		ep.Podcast = p
+		page.Episodes = append(page.Episodes, ep)
+	}
+	return &page, nil
+}
+
+// jsonAuthor is the JSON representation of an author in a JSON Feed.
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+// jsonItem is the JSON representation of an item in a JSON Feed.
+type jsonItem struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	Title       string   `json:"title"`
+	ContentHTML string   `json:"content_html"`
+	ContentText string   `json:"content_text"`
+	Summary     string   `json:"summary"`
+	Image       string   `json:"image"`
+	Published   string   `json:"date_published"`
+	Tags        []string `json:"tags"`
+	Attachments []struct {
+		URL      string  `json:"url"`
+		MimeType string  `json:"mime_type"`
+		Size     int64   `json:"size_in_bytes"`
+		Duration float64 `json:"duration_in_seconds"`
+	} `json:"attachments"`
+}
+
+// episode converts the item into an Episode, using its attachments as the
+// episode enclosures. The error, if not nil, describes a problem with the
+// publication date.
+func (i jsonItem) episode() (Episode, error) {
+	published, dateErr := parseDate(i.Published)
+	ep := Episode{
+		ID:        i.ID,
+		Title:     i.Title,
+		Link:      i.URL,
+		Desc:      i.ContentHTML,
+		Image:     i.Image,
+		Published: published,
+		Type:      "full",
+		Tags:      i.Tags,
+	}
+	if ep.Desc == "" {
+		ep.Desc = i.ContentText
+	}
+	if ep.Desc == "" {
+		ep.Desc = i.Summary
+	}
+	for _, a := range i.Attachments {
+		ep.Enclosures = append(ep.Enclosures, newEnclosure(a.URL, a.MimeType, a.Size, 0))
+		if ep.Duration == 0 {
+			ep.Duration = time.Duration(a.Duration * float64(time.Second))
+		}
+	}
+	return ep, dateErr
+}
@@ -0,0 +1,198 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// A Diagnostic describes a problem found while fetching a feed that did not
+// prevent it. Skipped items could not be decoded in lenient mode, or their
+// episode ID had already been found by Fetch, in which case Err wraps
+// ErrDuplicateID. Problems with the feed cache wrap ErrCache.
+type Diagnostic struct {
+	Source  string // Source of the feed, as given to Fetch; empty for Parse.
+	Item    int    // Position of the item in the feed document, starting at 1; 0 if unknown.
+	Title   string // Title of the item, if it could be found.
+	Line    int    // Line of the feed where decoding failed, starting at 1; 0 if unknown.
+	Column  int    // Column of the feed where decoding failed, in characters, starting at 1.
+	Skipped bool   // Whether the item was left out of the returned episodes.
+	Err     error
+}
+
+func (d Diagnostic) String() string {
+	var item string
+	switch {
+	case d.Item > 0:
+		item = fmt.Sprintf("item %d", d.Item)
+	case d.Title != "":
+		item = "episode"
+	}
+	if d.Title != "" {
+		item += fmt.Sprintf(" (%q)", d.Title)
+	}
+	if d.Skipped {
+		item += " skipped"
+	}
+	if d.Line > 0 {
+		item += fmt.Sprintf(" at line %d, column %d", d.Line, d.Column)
+	}
+	item = strings.TrimSpace(item)
+	if d.Source != "" && item != "" {
+		item = d.Source + ": " + item
+	} else if d.Source != "" {
+		item = d.Source
+	}
+	if item == "" {
+		return d.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", item, d.Err)
+}
+
+// decodeXMLItems decodes the XML document in data into v, which must be a
+// pointer. In lenient mode, the elements with the given item name that can
+// not be decoded are skipped and reported as diagnostics, as are the ones
+// found outside of their parent element, which would be lost otherwise.
+// The parent is the name of the element containing the items.
+func decodeXMLItems(data []byte, v interface{}, item, parent string, lenient bool) ([]Diagnostic, error) {
+	data = transcode(data)
+	if !lenient {
+		return nil, decodeXML(data, v)
+	}
+
+	// Items are decoded strictly, one at a time: every item that fails is
+	// blanked out in a copy of the document, preserving line breaks, so
+	// offsets and positions remain valid in the original, and decoding starts
+	// over.
+	orig := fixEncoding(data)
+	data = append([]byte(nil), orig...)
+	open := regexp.MustCompile("<" + regexp.QuoteMeta(item) + `[\s/>]`)
+	var diags []Diagnostic
+	for {
+		err := decodeXML(data, v)
+		if err == nil {
+			return append(diags, strayItems(data, item, parent)...), nil
+		}
+		derr, ok := err.(*DecodeError)
+		if !ok {
+			return diags, err
+		}
+		start, end := itemBounds(data, open, "</"+parent, derr.offset)
+		if start < 0 {
+			return diags, err
+		}
+
+		diags = append(diags, Diagnostic{
+			Item:    len(open.FindAllIndex(orig[:start], -1)) + 1,
+			Title:   itemTitle(orig[start:end]),
+			Line:    derr.Line,
+			Column:  derr.Column,
+			Skipped: true,
+			Err:     derr.Err,
+		})
+		for i := start; i < end; i++ {
+			if data[i] != '\n' {
+				data[i] = ' '
+			}
+		}
+		rv := reflect.ValueOf(v).Elem()
+		rv.Set(reflect.Zero(rv.Type()))
+	}
+}
+
+// errStrayItem is reported for the items that are not in their parent.
+var errStrayItem = errors.New("item is not a child of the feed")
+
+// strayItems returns diagnostics for the items in the well formed XML document
+// in data that are not direct children of a parent element, such as items
+// nested in another one, which are never decoded.
+func strayItems(data []byte, item, parent string) []Diagnostic {
+	var diags []Diagnostic
+	var stack []string
+	n := 0
+	dec := newXMLDecoder(data)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return diags
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == item {
+				n++
+				if len(stack) == 0 || stack[len(stack)-1] != parent {
+					d := newDecodeError(data, dec.InputOffset(), errStrayItem)
+					diags = append(diags, Diagnostic{Item: n, Line: d.Line, Column: d.Column, Skipped: true, Err: errStrayItem})
+				}
+			}
+			stack = append(stack, t.Name.Local)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// itemPosition returns the position in the feed document of the n-th decoded
+// item, or 0 if it is unknown because some items were skipped.
+func itemPosition(n int, skipped []Diagnostic) int {
+	if len(skipped) > 0 {
+		return 0
+	}
+	return n
+}
+
+// itemBounds returns the start and end offsets of the item containing the
+// given offset, or -1 if the offset is not inside an item. An item ends where
+// the next one starts, or where its parent is closed.
+func itemBounds(data []byte, open *regexp.Regexp, closeParent string, offset int64) (int, int) {
+	start, end := -1, -1
+	for _, loc := range open.FindAllIndex(data, -1) {
+		if int64(loc[0]) < offset {
+			start = loc[0]
+		} else if start >= 0 {
+			end = loc[0]
+			break
+		}
+	}
+	if start < 0 {
+		return -1, -1
+	}
+	if end < 0 {
+		end = bytes.LastIndex(data, []byte(closeParent))
+		if end < start {
+			return -1, -1
+		}
+	}
+	return start, end
+}
+
+var titleRE = regexp.MustCompile(`(?s)<title[^>]*>(.*?)</title>`)
+
+// itemTitle returns the title found in the raw XML of an item, if any.
+func itemTitle(item []byte) string {
+	m := titleRE.FindSubmatch(item)
+	if m == nil {
+		return ""
+	}
+	title := strings.TrimSpace(string(m[1]))
+	title = strings.TrimPrefix(title, "<![CDATA[")
+	title = strings.TrimSuffix(title, "]]>")
+	return strings.TrimSpace(title)
+}
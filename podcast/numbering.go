@@ -0,0 +1,100 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// A NumberSource describes how the number of an episode was obtained.
+type NumberSource string
+
+// Sources of episode numbers, in order of preference.
+const (
+	NumberFromEpisode NumberSource = "episode" // The itunes:episode or podcast:episode elements.
+	NumberFromOrder   NumberSource = "order"   // The legacy order element.
+	NumberFromTitle   NumberSource = "title"   // A match of the title regular expression.
+	NumberFromDate    NumberSource = "date"    // The position of the episode by publication date.
+)
+
+// DefaultTitleNumber matches episode numbers written as "#42" in titles.
+var DefaultTitleNumber = regexp.MustCompile(`#(\d+)`)
+
+// inferNumbers numbers the episodes without a number in the feed, by looking
+// for one in their title and falling back to their chronological position
+// among the numbers not taken by other episodes.
+func inferNumbers(eps []Episode, title *regexp.Regexp) {
+	missing := false
+	for i := range eps {
+		if eps[i].Number != 0 {
+			continue
+		}
+		if n := titleNumber(title, eps[i].Title); n > 0 {
+			eps[i].Number, eps[i].NumberSource = n, NumberFromTitle
+			continue
+		}
+		missing = true
+	}
+	if !missing {
+		return
+	}
+
+	// Feeds usually list the newest episodes first, so we start from the
+	// reverse feed order, which is used for episodes with the same date.
+	order := make([]int, len(eps))
+	for i := range order {
+		order[i] = len(eps) - 1 - i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return eps[order[i]].Published.Before(eps[order[j]].Published)
+	})
+
+	// Numbers found in the feed or in titles are never reused, so the rest
+	// get the lowest free numbers in chronological order.
+	used := map[int]bool{}
+	for _, ep := range eps {
+		used[ep.Number] = true
+	}
+	n := 1
+	for _, i := range order {
+		if eps[i].Number != 0 {
+			continue
+		}
+		for used[n] {
+			n++
+		}
+		eps[i].Number, eps[i].NumberSource = n, NumberFromDate
+		used[n] = true
+	}
+}
+
+// titleNumber returns the episode number found in the title by the given
+// regular expression, or zero if there is none.
+func titleNumber(re *regexp.Regexp, title string) int {
+	m := re.FindStringSubmatch(title)
+	if m == nil {
+		return 0
+	}
+	s := m[0]
+	if len(m) > 1 {
+		s = m[1]
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+	return n
+}
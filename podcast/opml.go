@@ -0,0 +1,74 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// An Outline is a feed listed in an OPML subscription list, as exported by
+// most podcast applications.
+type Outline struct {
+	Title string // The title attribute, or the text one if missing.
+	URL   string // The xmlUrl attribute.
+	// Attrs contains all the attributes of the outline, including any
+	// non standard ones, indexed by their local name.
+	Attrs map[string]string
+}
+
+// opmlOutline is the XML representation of an outline in an OPML document.
+type opmlOutline struct {
+	Attrs   []xml.Attr    `xml:",any,attr"`
+	Outline []opmlOutline `xml:"outline"`
+}
+
+// ParseOPML returns the feeds listed in the OPML document read from r.
+// Nested outlines, often used to group feeds in categories, are flattened,
+// and outlines without a feed URL are ignored.
+func ParseOPML(r io.Reader) ([]Outline, error) {
+	var doc struct {
+		XMLName xml.Name      `xml:"opml"`
+		Outline []opmlOutline `xml:"body>outline"`
+	}
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = charsetReader
+	dec.Entity = xml.HTMLEntity
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("could not decode OPML: %v", err)
+	}
+
+	var outlines []Outline
+	var walk func([]opmlOutline)
+	walk = func(children []opmlOutline) {
+		for _, o := range children {
+			attrs := make(map[string]string)
+			for _, a := range o.Attrs {
+				attrs[a.Name.Local] = strings.TrimSpace(a.Value)
+			}
+			if url := attrs["xmlUrl"]; url != "" {
+				title := attrs["title"]
+				if title == "" {
+					title = attrs["text"]
+				}
+				outlines = append(outlines, Outline{Title: title, URL: url, Attrs: attrs})
+			}
+			walk(o.Outline)
+		}
+	}
+	walk(doc.Outline)
+	return outlines, nil
+}
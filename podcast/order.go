@@ -0,0 +1,34 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+// An Order specifies how the episodes returned by a Fetcher are sorted.
+// Every order lists the oldest episodes first.
+type Order string
+
+// Supported episode orders.
+const (
+	OrderNumber Order = "number" // By episode number.
+	OrderDate   Order = "date"   // By publication date.
+	// OrderFeed is the reverse of the order in which the feed lists the
+	// episodes, since feeds list the newest episodes first.
+	OrderFeed Order = "feed"
+)
+
+// reverse reverses the order of the given episodes.
+func reverse(eps []Episode) {
+	for i, j := 0, len(eps)-1; i < j; i, j = i+1, j-1 {
+		eps[i], eps[j] = eps[j], eps[i]
+	}
+}
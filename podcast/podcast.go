@@ -14,63 +14,183 @@
 package podcast
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/xml"
 	"fmt"
-	"net/http"
-	"sort"
+	"io"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// atomNS is the XML namespace of Atom feeds.
+const atomNS = "http://www.w3.org/2005/Atom"
+
+// A Podcast contains the information about a show available at the
+// channel level of a podcast feed.
+type Podcast struct {
+	Title    string
+	Author   string
+	Image    string // URL of the show artwork, if any.
+	Language string
+	Link     string
+}
+
 // An Episode contains all the information available for a podcast
-// in a RSS stream.
+// in a podcast feed.
 type Episode struct {
-	Title  string
-	Number int
-	Link   string
-	Desc   string
-	MP3    string
-	Tags   []string
+	Podcast      *Podcast // The show the episode belongs to; never nil.
+	ID           string   // Stable identifier of the episode; never empty.
+	Title        string
+	Number       int
+	NumberSource NumberSource // How Number was obtained.
+	Season       int
+	Link         string
+	Desc         string
+	Media        string        // URL of the enclosure selected by the Fetcher.
+	Enclosures   []Enclosure   // All the enclosures, in feed order.
+	Image        string        // URL of the episode artwork, if any.
+	Published    time.Time     // Zero if the feed does not provide it.
+	Duration     time.Duration // Zero if the feed does not provide it.
+	Type         string        // One of "full", "trailer", or "bonus".
+	Explicit     bool
+	Tags         []string
+	Persons      []Person     // People involved in the episode, or in the show.
+	ChaptersURL  string       // URL of the Podcasting 2.0 JSON chapters, if any.
+	Chapters     []Chapter    // Filled by Fetcher.FetchChapters.
+	Transcripts  []Transcript // Transcripts, without cues until fetched.
+}
+
+// FetchFeed fetches a list of episodes for a podcast given its feed URL.
+// RSS, Atom, and JSON Feed documents are supported.
+// It is a shorthand for calling Fetch on a zero Fetcher.
+func FetchFeed(feed string) ([]Episode, error) {
+	return new(Fetcher).Fetch(context.Background(), feed)
+}
+
+// Parse decodes the episodes in the feed read from r, sorted by number.
+// It is a shorthand for calling Parse on a zero Fetcher.
+func Parse(r io.Reader, contentType string) ([]Episode, error) {
+	return new(Fetcher).Parse(r, contentType)
+}
+
+// A feedPage is a single document of a feed, which might be split in pages
+// or archives as described in RFC 5005.
+type feedPage struct {
+	Episodes    []Episode    // Episodes in the page, in feed order.
+	Next        string       // URL of the next page or archive, if any.
+	Diagnostics []Diagnostic `json:"-"` // Items skipped in lenient mode, and invalid dates.
+}
+
+// parseFeed detects the format of a feed given its content type and its
+// contents, and decodes it with the corresponding parser.
+// It returns ErrNoItems if the feed contains no episodes.
+// Episodes without an identifier are given one based on their enclosure.
+// In lenient mode malformed XML documents are accepted when possible, and
+// items that can not be decoded are skipped and reported in the page.
+func parseFeed(contentType string, data []byte, lenient bool) (*feedPage, error) {
+	p, err := parseFormat(contentType, data, lenient)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Episodes) == 0 {
+		return nil, ErrNoItems
+	}
+	for i := range p.Episodes {
+		if p.Episodes[i].ID == "" {
+			p.Episodes[i].ID = fallbackID(p.Episodes[i])
+		}
+	}
+	return p, nil
+}
+
+// fallbackID returns the identifier of an episode that has no identifier
+// in the feed, derived from the URL of its first enclosure or, if it has
+// none, from its title and link.
+func fallbackID(ep Episode) string {
+	key := ep.Title + "\n" + ep.Link
+	if len(ep.Enclosures) > 0 {
+		key = ep.Enclosures[0].URL
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(key)))
 }
 
-// FetchFeed fetches a list of episodes for a podcast given its RSS feed URL.
-func FetchFeed(rss string) ([]Episode, error) {
-	res, err := http.Get(rss)
+func parseFormat(contentType string, data []byte, lenient bool) (*feedPage, error) {
+	if strings.Contains(contentType, "json") || bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return parseJSONFeed(data)
+	}
+
+	root, err := rootElement(data, lenient)
 	if err != nil {
-		return nil, fmt.Errorf("could not get %s: %v", rss, err)
+		return nil, err
+	}
+	switch {
+	case root.Local == "rss":
+		return parseRSS(data, lenient)
+	case root.Local == "feed" && root.Space == atomNS:
+		return parseAtom(data, lenient)
+	default:
+		return nil, fmt.Errorf("unknown feed format with root element %q", root.Local)
 	}
-	defer res.Body.Close()
-
-	var data struct {
-		XMLName xml.Name `xml:"rss"`
-		Channel []struct {
-			Item []struct {
-				Title  string `xml:"title"`
-				Number int    `xml:"order"`
-				Link   string `xml:"guid"`
-				Desc   string `xml:"summary"`
-				MP3    struct {
-					URL string `xml:"url,attr"`
-				} `xml:"enclosure"`
-				Category []string `xml:"category"`
-			} `xml:"item"`
-		} `xml:"channel"`
+}
+
+// rootElement returns the name of the first element in the given XML document.
+func rootElement(data []byte, lenient bool) (xml.Name, error) {
+	data = transcode(data)
+	if lenient {
+		data = fixEncoding(data)
 	}
+	dec := newXMLDecoder(data)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return xml.Name{}, newDecodeError(data, dec.InputOffset(), err)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name, nil
+		}
+	}
+}
 
-	if err := xml.NewDecoder(res.Body).Decode(&data); err != nil {
-		return nil, fmt.Errorf("could not decode feed: %v", err)
+// firstInt returns the first of the given strings that is a positive integer.
+// The Podcasting 2.0 namespace allows decimal episode numbers such as "12.5",
+// for those only the integer part is kept.
+func firstInt(ss ...string) int {
+	for _, s := range ss {
+		s = strings.TrimSpace(s)
+		if i := strings.Index(s, "."); i >= 0 {
+			s = s[:i]
+		}
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			return n
+		}
 	}
+	return 0
+}
 
-	var eps []Episode
-	for _, i := range data.Channel[0].Item {
-		eps = append(eps, Episode{
-			Title:  i.Title,
-			Number: i.Number,
-			Link:   i.Link,
-			Desc:   i.Desc,
-			MP3:    i.MP3.URL,
-			Tags:   i.Category,
-		})
+// parseDuration parses the value of an itunes:duration element, which can be
+// expressed as a number of seconds or as HH:MM:SS or MM:SS.
+// It returns zero if the value can not be parsed.
+func parseDuration(s string) time.Duration {
+	var d time.Duration
+	for _, p := range strings.Split(strings.TrimSpace(s), ":") {
+		n, err := strconv.ParseFloat(p, 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		d = 60*d + time.Duration(n*float64(time.Second))
 	}
+	return d
+}
 
-	sort.Slice(eps, func(i, j int) bool { return eps[i].Number < eps[j].Number })
-	return eps, nil
+// parseExplicit parses the value of an itunes:explicit element.
+func parseExplicit(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "yes", "true", "explicit":
+		return true
+	default:
+		return false
+	}
 }
@@ -0,0 +1,862 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const itunesFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"
+	xmlns:podcast="https://podcastindex.org/namespace/1.0"
+	xmlns:atom="http://www.w3.org/2005/Atom">
+<channel>
+	<title>Test Show</title>
+	<link>http://example.com</link>
+	<atom:link href="http://example.com/feed.xml" rel="self" type="application/rss+xml"/>
+	<language>en</language>
+	<itunes:author>Gopher</itunes:author>
+	<item>
+		<title>Second</title>
+		<itunes:title>Second (iTunes)</itunes:title>
+		<itunes:episode>2</itunes:episode>
+		<itunes:season>1</itunes:season>
+		<itunes:duration>1:02:03</itunes:duration>
+		<itunes:image href="http://example.com/2.png"/>
+		<itunes:episodeType>bonus</itunes:episodeType>
+		<itunes:explicit>yes</itunes:explicit>
+		<guid>http://example.com/2</guid>
+		<enclosure url="http://example.com/2.mp3" type="audio/mpeg" length="1"/>
+	</item>
+	<item>
+		<title>First</title>
+		<podcast:episode>1</podcast:episode>
+		<podcast:season>1</podcast:season>
+		<itunes:duration>90</itunes:duration>
+		<itunes:explicit>false</itunes:explicit>
+		<link>http://example.com/1</link>
+		<guid isPermaLink="false">episode-1</guid>
+		<enclosure url="http://example.com/1.mp3" type="audio/mpeg" length="1"/>
+	</item>
+</channel>
+</rss>`
+
+func serve(t *testing.T, body string) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestFetchFeedNamespaces(t *testing.T) {
+	s := serve(t, itunesFeed)
+	eps, err := FetchFeed(s.URL)
+	if err != nil {
+		t.Fatalf("could not fetch feed: %v", err)
+	}
+	show := &Podcast{Title: "Test Show", Author: "Gopher", Language: "en", Link: "http://example.com"}
+	want := []Episode{
+		{
+			Podcast:      show,
+			ID:           "episode-1",
+			Title:        "First",
+			Number:       1,
+			NumberSource: NumberFromEpisode,
+			Season:       1,
+			Link:         "http://example.com/1",
+			Media:        "http://example.com/1.mp3",
+			Enclosures:   []Enclosure{{URL: "http://example.com/1.mp3", Type: "audio/mpeg", Length: 1}},
+			Duration:     90 * time.Second,
+			Type:         "full",
+		},
+		{
+			Podcast:      show,
+			ID:           "http://example.com/2",
+			Title:        "Second",
+			Number:       2,
+			NumberSource: NumberFromEpisode,
+			Season:       1,
+			Link:         "http://example.com/2",
+			Media:        "http://example.com/2.mp3",
+			Enclosures:   []Enclosure{{URL: "http://example.com/2.mp3", Type: "audio/mpeg", Length: 1}},
+			Image:        "http://example.com/2.png",
+			Duration:     time.Hour + 2*time.Minute + 3*time.Second,
+			Type:         "bonus",
+			Explicit:     true,
+		},
+	}
+	if !reflect.DeepEqual(eps, want) {
+		t.Errorf("wrong episodes:\nwant %+v\ngot  %+v", want, eps)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"42", 42 * time.Second},
+		{"3:04", 3*time.Minute + 4*time.Second},
+		{"01:02:03", time.Hour + 2*time.Minute + 3*time.Second},
+		{"not a duration", 0},
+	}
+	for _, tt := range tests {
+		if got := parseDuration(tt.in); got != tt.want {
+			t.Errorf("parseDuration(%q) = %v; want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+const atomFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>Test Show</title>
+	<entry>
+		<title>First</title>
+		<id>urn:uuid:1</id>
+		<link rel="alternate" href="http://example.com/1"/>
+		<link rel="enclosure" type="audio/mpeg" href="http://example.com/1.mp3"/>
+		<summary>The first one.</summary>
+		<category term="go"/>
+	</entry>
+</feed>`
+
+const jsonFeed = `{
+	"version": "https://jsonfeed.org/version/1.1",
+	"title": "Test Show",
+	"items": [{
+		"id": "1",
+		"url": "http://example.com/1",
+		"title": "First",
+		"content_text": "The first one.",
+		"tags": ["go"],
+		"attachments": [
+			{"url": "http://example.com/1.txt", "mime_type": "text/plain"},
+			{"url": "http://example.com/1.mp3", "mime_type": "audio/mpeg", "duration_in_seconds": 60}
+		]
+	}]
+}`
+
+func TestFetchFeedFormats(t *testing.T) {
+	tests := []struct {
+		name string
+		feed string
+		want Episode
+	}{
+		{"atom", atomFeed, Episode{
+			Podcast:      &Podcast{Title: "Test Show"},
+			ID:           "urn:uuid:1",
+			Title:        "First",
+			Number:       1,
+			NumberSource: NumberFromDate,
+			Link:         "http://example.com/1",
+			Desc:         "The first one.",
+			Media:        "http://example.com/1.mp3",
+			Enclosures:   []Enclosure{{URL: "http://example.com/1.mp3", Type: "audio/mpeg"}},
+			Type:         "full",
+			Tags:         []string{"go"},
+		}},
+		{"json", jsonFeed, Episode{
+			Podcast:      &Podcast{Title: "Test Show"},
+			ID:           "1",
+			Title:        "First",
+			Number:       1,
+			NumberSource: NumberFromDate,
+			Link:         "http://example.com/1",
+			Desc:         "The first one.",
+			Media:        "http://example.com/1.mp3",
+			Enclosures: []Enclosure{
+				{URL: "http://example.com/1.txt", Type: "text/plain"},
+				{URL: "http://example.com/1.mp3", Type: "audio/mpeg"},
+			},
+			Duration: time.Minute,
+			Type:     "full",
+			Tags:     []string{"go"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eps, err := FetchFeed(serve(t, tt.feed).URL)
+			if err != nil {
+				t.Fatalf("could not fetch feed: %v", err)
+			}
+			if want := []Episode{tt.want}; !reflect.DeepEqual(eps, want) {
+				t.Errorf("wrong episodes:\nwant %+v\ngot  %+v", want, eps)
+			}
+		})
+	}
+}
+
+func TestFetchSources(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "feed.xml")
+	if err := ioutil.WriteFile(path, []byte(itunesFeed), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &Fetcher{Stdin: strings.NewReader(jsonFeed)}
+	for _, source := range []string{path, "file://" + filepath.ToSlash(path), "-"} {
+		eps, err := f.Fetch(context.Background(), source)
+		if err != nil {
+			t.Errorf("could not fetch %s: %v", source, err)
+			continue
+		}
+		if len(eps) == 0 {
+			t.Errorf("no episodes found in %s", source)
+		}
+	}
+
+	if _, err := f.Fetch(context.Background(), "ftp://example.com/feed.xml"); err == nil {
+		t.Errorf("expected error for unsupported scheme")
+	}
+}
+
+func TestFetchCache(t *testing.T) {
+	requests := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, itunesFeed)
+	}))
+	defer s.Close()
+
+	f := &Fetcher{CacheDir: t.TempDir()}
+	first, err := f.Fetch(context.Background(), s.URL)
+	if err != nil {
+		t.Fatalf("could not fetch feed: %v", err)
+	}
+	second, err := f.Fetch(context.Background(), s.URL)
+	if err != nil {
+		t.Fatalf("could not fetch cached feed: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests; got %d", requests)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("cached episodes differ:\nwant %+v\ngot  %+v", first, second)
+	}
+}
+
+func TestFetchOldCache(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, itunesFeed)
+	}))
+	defer s.Close()
+
+	f := &Fetcher{CacheDir: t.TempDir()}
+	old := fmt.Sprintf(`{"Version": 1, "URL": %q, "ETag": "\"v1\"", "Page": {}}`, s.URL)
+	if err := ioutil.WriteFile(f.cachePath(s.URL), []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if f.loadCache(s.URL) != nil {
+		t.Errorf("expected cache entry of version 1 to be ignored")
+	}
+	eps, err := f.Fetch(context.Background(), s.URL)
+	if err != nil {
+		t.Fatalf("could not fetch feed: %v", err)
+	}
+	if len(eps) == 0 {
+		t.Errorf("expected episodes from the feed, not from the old cache entry")
+	}
+}
+
+func TestFetchCorruptCache(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			t.Errorf("unexpected conditional request with a corrupt cache")
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, itunesFeed)
+	}))
+	defer s.Close()
+
+	var diags []Diagnostic
+	f := &Fetcher{CacheDir: t.TempDir(), Report: func(d Diagnostic) { diags = append(diags, d) }}
+	if err := ioutil.WriteFile(f.cachePath(s.URL), []byte(`{"Version": 1, "URL": "`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	eps, err := f.Fetch(context.Background(), s.URL)
+	if err != nil {
+		t.Fatalf("could not fetch feed with a corrupt cache: %v", err)
+	}
+	if len(eps) == 0 {
+		t.Errorf("no episodes found")
+	}
+	if len(diags) != 1 || !errors.Is(diags[0].Err, ErrCache) {
+		t.Errorf("expected a cache diagnostic; got %v", diags)
+	}
+	if f.loadCache(s.URL) == nil {
+		t.Errorf("expected the corrupt cache entry to be replaced")
+	}
+
+	// A cache that can not be written is reported too.
+	diags = nil
+	f.CacheDir = f.cachePath(s.URL)
+	if _, err := f.Fetch(context.Background(), s.URL); err != nil {
+		t.Fatalf("could not fetch feed with an unwritable cache: %v", err)
+	}
+	if len(diags) == 0 {
+		t.Errorf("expected cache diagnostics")
+	}
+	for _, d := range diags {
+		if !errors.Is(d.Err, ErrCache) {
+			t.Errorf("expected a cache diagnostic; got %v", d)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		feed string
+		want error
+	}{
+		{"no channel", `<rss version="2.0"></rss>`, ErrNoChannel},
+		{"no items", `<rss version="2.0"><channel><title>Empty</title></channel></rss>`, ErrNoItems},
+		{"empty json", `{"version": "https://jsonfeed.org/version/1.1", "items": []}`, ErrNoItems},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.feed), ""); err != tt.want {
+				t.Errorf("expected error %v; got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestParseDecodeError(t *testing.T) {
+	_, err := Parse(strings.NewReader("<rss>\n<channel>\n  <item></channel>\n</rss>"), "")
+	derr, ok := err.(*DecodeError)
+	if !ok {
+		t.Fatalf("expected a *DecodeError; got %T: %v", err, err)
+	}
+	if derr.Line != 3 {
+		t.Errorf("expected error at line 3; got line %d, column %d", derr.Line, derr.Column)
+	}
+}
+
+func TestInferNumbers(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC) }
+	eps := []Episode{
+		{Title: "Latest", Published: day(4)},
+		{Title: "Interview #3", Published: day(3)},
+		{Title: "Tagged", Number: 2, NumberSource: NumberFromEpisode, Published: day(2)},
+		{Title: "Pilot", Published: day(1)},
+	}
+	inferNumbers(eps, DefaultTitleNumber)
+
+	want := []struct {
+		n   int
+		src NumberSource
+	}{
+		{4, NumberFromDate},
+		{3, NumberFromTitle},
+		{2, NumberFromEpisode},
+		{1, NumberFromDate},
+	}
+	for i, w := range want {
+		if eps[i].Number != w.n || eps[i].NumberSource != w.src {
+			t.Errorf("episode %q: expected number %d from %s; got %d from %s",
+				eps[i].Title, w.n, w.src, eps[i].Number, eps[i].NumberSource)
+		}
+	}
+}
+
+func TestInferNumbersMixed(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC) }
+	eps := []Episode{
+		{Title: "Bonus", Published: day(5)},
+		{Title: "Episode #2", Published: day(4)},
+		{Title: "Holiday special", Published: day(3)},
+		{Title: "Episode #1", Published: day(2)},
+		{Title: "Trailer", Published: day(1)},
+	}
+	inferNumbers(eps, DefaultTitleNumber)
+
+	want := []int{5, 2, 4, 1, 3}
+	for i, n := range want {
+		if eps[i].Number != n {
+			t.Errorf("episode %q: expected number %d; got %d", eps[i].Title, n, eps[i].Number)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	want := time.Date(2017, 8, 2, 14, 30, 0, 0, time.UTC)
+	tests := []string{
+		"Wed, 02 Aug 2017 14:30:00 +0000",
+		"Wed, 02 Aug 2017 14:30:00 GMT",
+		"Wed, 2 Aug 2017 16:30:00 +02:00",
+		"Mon, 02 Aug 2017 14:30:00 UT",
+		"02 Aug 2017 07:30 PDT",
+		"Wednesday, 2 August 2017 14:30:00 Z",
+		"Wed 02 Aug 17 16:30:00 GMT+2",
+		"Wed,  02 Aug 2017   14:30:00",
+		"2017-08-02T16:30:00+02:00",
+		"Thu, 03 Aug 2017 00:30:00 AEST",
+		"Wed, 02 Aug 2017 16:30:00 EET",
+		"Wed, 02 Aug 2017 11:30:00 BRT",
+		"Wed, 02 Aug. 2017 2:30 PM",
+		"Wed, 02 Aug 2017 9:30:00 am CDT",
+	}
+	for _, s := range tests {
+		got, err := parseDate(s)
+		if !got.Equal(want) || err != nil {
+			t.Errorf("parseDate(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+
+	got, err := parseDate("Sat, 2 Sept 2017 14:30:00 GMT")
+	if !got.Equal(want.AddDate(0, 1, 0)) || err != nil {
+		t.Errorf("parseDate of a date in Sept = %v, %v", got, err)
+	}
+
+	// Unknown time zones are reported, and ignored.
+	for _, s := range []string{"Wed, 02 Aug 2017 14:30:00 NZXT", "Wed, 02 Aug 2017 14:30 XYZ+3"} {
+		got, err := parseDate(s)
+		if !got.Equal(want) || !errors.Is(err, ErrInvalidDate) {
+			t.Errorf("parseDate(%q) = %v, %v; want %v and ErrInvalidDate", s, got, err, want)
+		}
+	}
+	if got, err := parseDate("yesterday"); !got.IsZero() || !errors.Is(err, ErrInvalidDate) {
+		t.Errorf("parseDate(%q) = %v, %v; want zero time and ErrInvalidDate", "yesterday", got, err)
+	}
+	if got, err := parseDate(""); !got.IsZero() || err != nil {
+		t.Errorf("parseDate of an empty date = %v, %v; want zero time and no error", got, err)
+	}
+}
+
+func TestInvalidDateDiagnostics(t *testing.T) {
+	const feed = `<rss version="2.0"><channel><title>Show</title>
+	<item><title>Good</title><guid>1</guid><pubDate>Wed, 02 Aug 2017 14:30:00 GMT</pubDate></item>
+	<item><title>Bad</title><guid>2</guid><pubDate>someday</pubDate></item>
+	</channel></rss>`
+	var diags []Diagnostic
+	f := &Fetcher{Report: func(d Diagnostic) { diags = append(diags, d) }}
+	if _, err := f.Parse(strings.NewReader(feed), ""); err != nil {
+		t.Fatalf("could not parse feed: %v", err)
+	}
+	if len(diags) != 1 || diags[0].Item != 2 || diags[0].Title != "Bad" || diags[0].Skipped || !errors.Is(diags[0].Err, ErrInvalidDate) {
+		t.Errorf("expected an invalid date diagnostic for item 2; got %v", diags)
+	}
+}
+
+func TestFetcherOrder(t *testing.T) {
+	const feed = `<rss><channel>
+		<item><title>B</title><order>1</order><pubDate>Wed, 02 Aug 2017 00:00:00 GMT</pubDate></item>
+		<item><title>C</title><order>3</order><pubDate>Thu, 03 Aug 2017 00:00:00 GMT</pubDate></item>
+		<item><title>A</title><order>2</order><pubDate>Tue, 01 Aug 2017 00:00:00 GMT</pubDate></item>
+	</channel></rss>`
+
+	tests := []struct {
+		order Order
+		want  string
+	}{
+		{OrderNumber, "BAC"},
+		{OrderDate, "ABC"},
+		{OrderFeed, "ACB"},
+	}
+	for _, tt := range tests {
+		f := &Fetcher{Order: tt.order}
+		eps, err := f.Parse(strings.NewReader(feed), "")
+		if err != nil {
+			t.Fatalf("could not parse feed: %v", err)
+		}
+		got := ""
+		for _, ep := range eps {
+			got += ep.Title
+		}
+		if got != tt.want {
+			t.Errorf("order %s: expected %s; got %s", tt.order, tt.want, got)
+		}
+	}
+
+	if _, err := (&Fetcher{Order: "random"}).Parse(strings.NewReader(feed), ""); err == nil {
+		t.Errorf("expected error for unknown order")
+	}
+}
+
+func TestEpisodeIDFallback(t *testing.T) {
+	const feed = `<rss><channel><item>
+		<title>No guid</title>
+		<enclosure url="http://example.com/1.mp3"/>
+	</item></channel></rss>`
+
+	eps, err := Parse(strings.NewReader(feed), "")
+	if err != nil {
+		t.Fatalf("could not parse feed: %v", err)
+	}
+	if want := fmt.Sprintf("%x", sha256.Sum256([]byte("http://example.com/1.mp3"))); eps[0].ID != want {
+		t.Errorf("expected ID %q; got %q", want, eps[0].ID)
+	}
+	if eps[0].Link != "" {
+		t.Errorf("expected no link; got %q", eps[0].Link)
+	}
+}
+
+func TestFetchPages(t *testing.T) {
+	pages := map[string]string{
+		"/feed": `<rss xmlns:atom="http://www.w3.org/2005/Atom"><channel>
+			<atom:link rel="next" href="/feed?page=2"/>
+			<item><guid>3</guid><order>3</order></item>
+			<item><guid>2</guid><order>2</order></item>
+		</channel></rss>`,
+		"/feed?page=2": `<rss xmlns:atom="http://www.w3.org/2005/Atom"><channel>
+			<atom:link rel="next" href="/feed?page=3"/>
+			<item><guid>2</guid><order>2</order></item>
+			<item><guid>1</guid><order>1</order></item>
+		</channel></rss>`,
+		"/feed?page=3": `<rss><channel>
+			<item><guid>0</guid><title>Trailer</title></item>
+			<item><guid>0</guid><title>Trailer again</title></item>
+		</channel></rss>`,
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, pages[r.URL.RequestURI()])
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		maxPages int
+		want     string
+	}{
+		{0, "23"},
+		{2, "123"},
+		{10, "0123"},
+	}
+	for _, tt := range tests {
+		var dups []string
+		f := &Fetcher{MaxPages: tt.maxPages, Order: OrderFeed, Report: func(d Diagnostic) {
+			if !errors.Is(d.Err, ErrDuplicateID) {
+				t.Errorf("unexpected diagnostic: %v", d)
+			}
+			dups = append(dups, d.Title)
+		}}
+		eps, err := f.Fetch(context.Background(), s.URL+"/feed")
+		if err != nil {
+			t.Fatalf("could not fetch feed: %v", err)
+		}
+		got := ""
+		for _, ep := range eps {
+			got += ep.ID
+		}
+		if got != tt.want {
+			t.Errorf("with %d pages expected episodes %s; got %s", tt.maxPages, tt.want, got)
+		}
+		// Only the episode repeated within a page is reported.
+		if wantDups := tt.maxPages >= 3; wantDups != (len(dups) == 1) {
+			t.Errorf("with %d pages expected duplicates %v; got %q", tt.maxPages, wantDups, dups)
+		}
+	}
+}
+
+func TestParseCharsetAndEntities(t *testing.T) {
+	feed := "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n" +
+		"<rss><channel><item><title>Caf\xe9&nbsp;\x93Go\x94</title><order>1</order></item></channel></rss>"
+
+	eps, err := Parse(strings.NewReader(feed), "")
+	if err != nil {
+		t.Fatalf("could not parse feed: %v", err)
+	}
+	if want := "Café “Go”"; eps[0].Title != want {
+		t.Errorf("expected title %q; got %q", want, eps[0].Title)
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	const feed = `<rss><channel>
+	<item><title>One</title><order>1</order></item>
+	<item><title>Two</title><order>2</order><description>a < b</description></item>
+	<item><title>Three</title><order>3</order><description><foo>x</bar></description></item>
+	<item><title>Four</title><order>4</order><description>see <a href="http://example.com">this</description></item>
+	<item><title>Five</title><order>5</order></item>
+</channel></rss>`
+
+	if _, err := Parse(strings.NewReader(feed), ""); err == nil {
+		t.Fatalf("expected error parsing malformed feed in strict mode")
+	}
+
+	var diags []Diagnostic
+	f := &Fetcher{Lenient: true, Report: func(d Diagnostic) { diags = append(diags, d) }}
+	eps, err := f.Parse(strings.NewReader(feed), "")
+	if err != nil {
+		t.Fatalf("could not parse feed in lenient mode: %v", err)
+	}
+	if len(eps) != 2 || eps[0].Title != "One" || eps[1].Title != "Five" {
+		t.Errorf("expected episodes One and Five; got %+v", eps)
+	}
+	want := []struct {
+		item  int
+		title string
+		line  int
+	}{{2, "Two", 3}, {3, "Three", 4}, {4, "Four", 5}}
+	if len(diags) != len(want) {
+		t.Fatalf("expected %d diagnostics; got %v", len(want), diags)
+	}
+	for i, w := range want {
+		if d := diags[i]; d.Item != w.item || d.Title != w.title || d.Line != w.line || !d.Skipped {
+			t.Errorf("expected a diagnostic for item %d (%q) at line %d; got %v", w.item, w.title, w.line, d)
+		}
+	}
+}
+
+func TestParseLenientStrayItem(t *testing.T) {
+	const feed = `<rss><channel>
+	<item><title>One</title><order>1</order></item>
+	<item><title>Two</title><order>2</order>
+		<item><title>Three</title><order>3</order></item>
+	</item>
+</channel></rss>`
+
+	var diags []Diagnostic
+	f := &Fetcher{Lenient: true, Report: func(d Diagnostic) { diags = append(diags, d) }}
+	eps, err := f.Parse(strings.NewReader(feed), "")
+	if err != nil {
+		t.Fatalf("could not parse feed in lenient mode: %v", err)
+	}
+	if len(eps) != 2 {
+		t.Errorf("expected episodes One and Two; got %+v", eps)
+	}
+	if len(diags) != 1 || diags[0].Item != 3 || diags[0].Line != 4 {
+		t.Errorf("expected a diagnostic for item 3 at line 4; got %v", diags)
+	}
+}
+
+func TestParseLenientCharset(t *testing.T) {
+	const body = "<rss><channel>\n\t<item><title>One</title></item>\n" +
+		"\t<item><title>Caf\xe9 \x93Two\x94</title><description>a < b</description></item>\n" +
+		"</channel></rss>"
+	diagnose := func(feed string) Diagnostic {
+		var diags []Diagnostic
+		f := &Fetcher{Lenient: true, Report: func(d Diagnostic) { diags = append(diags, d) }}
+		if _, err := f.Parse(strings.NewReader(feed), ""); err != nil {
+			t.Fatalf("could not parse feed in lenient mode: %v", err)
+		}
+		if len(diags) != 1 {
+			t.Fatalf("expected one diagnostic; got %v", diags)
+		}
+		return diags[0]
+	}
+
+	// Positions are the same whether the charset is declared or not, and
+	// the title is decoded in both cases.
+	declared := diagnose(`<?xml version="1.0" encoding="windows-1252"?>` + body)
+	undeclared := diagnose(body)
+	if declared.Line != 3 || declared.Line != undeclared.Line || declared.Column != undeclared.Column {
+		t.Errorf("expected diagnostics at the same position; got %v and %v", declared, undeclared)
+	}
+	if declared.Title != "Café “Two”" || undeclared.Title != declared.Title {
+		t.Errorf("expected title %q; got %q and %q", "Café “Two”", declared.Title, undeclared.Title)
+	}
+}
+
+func TestEnclosurePolicy(t *testing.T) {
+	encs := []Enclosure{
+		{URL: "low.mp3", Type: "audio/mpeg", Length: 60000},
+		{URL: "high.mp3", Type: "audio/mpeg", Length: 120000},
+		{URL: "high.opus", Type: "audio/opus", Bitrate: 64000},
+		{URL: "episode.m4a", Type: "audio/x-m4a", Length: 90000},
+		{URL: "transcript.txt", Type: "text/plain"},
+	}
+	tests := []struct {
+		policy EnclosurePolicy
+		want   string
+	}{
+		{EnclosurePolicy{}, "high.mp3"},
+		{EnclosurePolicy{Types: []string{"audio/opus", "audio/*"}}, "high.opus"},
+		{EnclosurePolicy{Types: []string{"audio/mpeg"}}, "high.mp3"},
+		{EnclosurePolicy{Types: []string{"audio/mpeg"}, LowestBitrate: true}, "low.mp3"},
+		{EnclosurePolicy{Types: []string{"audio/mp4", "audio/x-m4a"}}, "episode.m4a"},
+		{EnclosurePolicy{Types: []string{"text/*"}}, "transcript.txt"},
+	}
+	for _, tt := range tests {
+		e, ok := tt.policy.Select(encs, 10*time.Second)
+		if !ok || e.URL != tt.want {
+			t.Errorf("policy %+v: expected %s; got %s", tt.policy, tt.want, e.URL)
+		}
+	}
+	if _, ok := (EnclosurePolicy{}).Select(nil, 0); ok {
+		t.Errorf("expected no enclosure selected from an empty list")
+	}
+}
+
+func TestPodcastingExtras(t *testing.T) {
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed.xml":
+			fmt.Fprintf(w, `<rss xmlns:podcast="https://podcastindex.org/namespace/1.0"><channel>
+				<podcast:person>Host</podcast:person>
+				<item>
+					<title>One</title>
+					<order>1</order>
+					<podcast:chapters url="%[1]s/chapters.json" type="application/json+chapters"/>
+					<podcast:transcript url="%[1]s/1.vtt" type="text/vtt" language="en" rel="captions"/>
+				</item>
+				<item>
+					<title>Two</title>
+					<order>2</order>
+					<podcast:person role="Guest" href="http://example.com">Guest</podcast:person>
+				</item>
+			</channel></rss>`, s.URL)
+		case "/chapters.json":
+			fmt.Fprint(w, `{"version": "1.2.0", "chapters": [
+				{"startTime": 0, "title": "Intro"},
+				{"startTime": 5, "title": "Hidden", "toc": false},
+				{"startTime": 30.5, "title": "Interview", "endTime": 60}
+			]}`)
+		case "/1.vtt":
+			fmt.Fprint(w, "WEBVTT\n\nNOTE a comment\n\n1\n00:00.000 --> 00:01.500 align:start\n<v Ann>Hello <b>there</b>\nworld\n\n00:00:01.500 --> 00:00:03.000\nBye\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	f := new(Fetcher)
+	eps, err := f.Fetch(context.Background(), s.URL+"/feed.xml")
+	if err != nil {
+		t.Fatalf("could not fetch feed: %v", err)
+	}
+
+	if want := []Person{{Name: "Host", Role: "host", Group: "cast"}}; !reflect.DeepEqual(eps[0].Persons, want) {
+		t.Errorf("expected persons %+v; got %+v", want, eps[0].Persons)
+	}
+	if want := []Person{{Name: "Guest", Role: "guest", Group: "cast", Href: "http://example.com"}}; !reflect.DeepEqual(eps[1].Persons, want) {
+		t.Errorf("expected persons %+v; got %+v", want, eps[1].Persons)
+	}
+
+	if err := f.FetchChapters(context.Background(), &eps[0]); err != nil {
+		t.Fatalf("could not fetch chapters: %v", err)
+	}
+	wantChapters := []Chapter{
+		{Start: 0, End: 30500 * time.Millisecond, Title: "Intro"},
+		{Start: 30500 * time.Millisecond, End: time.Minute, Title: "Interview"},
+	}
+	if !reflect.DeepEqual(eps[0].Chapters, wantChapters) {
+		t.Errorf("expected chapters %+v; got %+v", wantChapters, eps[0].Chapters)
+	}
+
+	tr := &eps[0].Transcripts[0]
+	if err := f.FetchTranscript(context.Background(), tr); err != nil {
+		t.Fatalf("could not fetch transcript: %v", err)
+	}
+	wantCues := []Cue{
+		{Start: 0, End: 1500 * time.Millisecond, Speaker: "Ann", Text: "Hello there\nworld"},
+		{Start: 1500 * time.Millisecond, End: 3 * time.Second, Text: "Bye"},
+	}
+	if !reflect.DeepEqual(tr.Cues, wantCues) {
+		t.Errorf("expected cues %+v; got %+v", wantCues, tr.Cues)
+	}
+}
+
+func TestParseTranscriptSRT(t *testing.T) {
+	const srt = "1\r\n00:00:01,000 --> 00:00:02,500\r\nFirst line\r\n\r\n2\r\n00:00:03,000 --> 00:00:04,000\r\nSecond\r\n"
+	cues, err := ParseTranscript([]byte(srt), "application/x-subrip")
+	if err != nil {
+		t.Fatalf("could not parse transcript: %v", err)
+	}
+	want := []Cue{
+		{Start: time.Second, End: 2500 * time.Millisecond, Text: "First line"},
+		{Start: 3 * time.Second, End: 4 * time.Second, Text: "Second"},
+	}
+	if !reflect.DeepEqual(cues, want) {
+		t.Errorf("expected cues %+v; got %+v", want, cues)
+	}
+}
+
+func TestFormatSRT(t *testing.T) {
+	cues := []Cue{
+		{Start: 1500 * time.Millisecond, End: 3 * time.Second, Speaker: "Ann", Text: "Hello"},
+		{Start: 3 * time.Second, End: 4 * time.Second, Speaker: "Ann", Text: "again"},
+		{Start: time.Hour + 2*time.Minute, End: time.Hour + 2*time.Minute + 5*time.Second, Speaker: "Bob", Text: "Bye\nnow"},
+	}
+	want := "1\n00:00:01,500 --> 00:00:03,000\nAnn: Hello\n\n" +
+		"2\n00:00:03,000 --> 00:00:04,000\nagain\n\n" +
+		"3\n01:02:00,000 --> 01:02:05,000\nBob: Bye\nnow\n\n"
+	srt := FormatSRT(cues)
+	if string(srt) != want {
+		t.Errorf("expected SRT %q; got %q", want, srt)
+	}
+	parsed, err := ParseTranscript(srt, "application/x-subrip")
+	if err != nil {
+		t.Fatalf("could not parse formatted transcript: %v", err)
+	}
+	if len(parsed) != len(cues) || parsed[2].Start != cues[2].Start || parsed[2].Text != "Bob: Bye\nnow" {
+		t.Errorf("unexpected cues after round trip: %+v", parsed)
+	}
+}
+
+func TestFormatSRTNoEnd(t *testing.T) {
+	cues := []Cue{
+		{Start: time.Second, Text: "One"},
+		{Start: 2 * time.Second, Text: "Two"},
+		{Start: 10 * time.Second, Text: "Three"},
+	}
+	want := "1\n00:00:01,000 --> 00:00:02,000\nOne\n\n" +
+		"2\n00:00:02,000 --> 00:00:05,000\nTwo\n\n" +
+		"3\n00:00:10,000 --> 00:00:13,000\nThree\n\n"
+	if srt := FormatSRT(cues); string(srt) != want {
+		t.Errorf("expected SRT %q; got %q", want, srt)
+	}
+}
+
+func TestParseOPML(t *testing.T) {
+	const doc = `<?xml version="1.0"?>
+<opml version="2.0">
+	<head><title>Network</title></head>
+	<body>
+		<outline text="Tech">
+			<outline type="rss" text="Go Show" xmlUrl="http://example.com/go.xml" playlist="PL1" fg="ffffff"/>
+		</outline>
+		<outline type="rss" title="Cloud Show" text="Cloud" xmlUrl="http://example.com/cloud.xml"/>
+	</body>
+</opml>`
+
+	outlines, err := ParseOPML(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("could not parse OPML: %v", err)
+	}
+	if len(outlines) != 2 {
+		t.Fatalf("expected 2 outlines; got %d", len(outlines))
+	}
+	if o := outlines[0]; o.Title != "Go Show" || o.URL != "http://example.com/go.xml" || o.Attrs["playlist"] != "PL1" || o.Attrs["fg"] != "ffffff" {
+		t.Errorf("unexpected first outline: %+v", o)
+	}
+	if o := outlines[1]; o.Title != "Cloud Show" || o.URL != "http://example.com/cloud.xml" {
+		t.Errorf("unexpected second outline: %+v", o)
+	}
+}
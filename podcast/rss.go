@@ -0,0 +1,243 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// parseRSS decodes the episodes in all the channels of a RSS 2.0 feed.
+func parseRSS(data []byte, lenient bool) (*feedPage, error) {
+	var feed struct {
+		XMLName xml.Name     `xml:"rss"`
+		Channel []rssChannel `xml:"channel"`
+	}
+
+	diags, err := decodeXMLItems(data, &feed, "item", "channel", lenient)
+	if err != nil {
+		return nil, err
+	}
+	if len(feed.Channel) == 0 {
+		return nil, ErrNoChannel
+	}
+
+	page := feedPage{Diagnostics: diags}
+	n := 0
+	for _, c := range feed.Channel {
+		p := c.podcast()
+		for _, i := range c.Item {
+			n++
+			ep, err := i.episode()
+			if err != nil {
+				page.Diagnostics = append(page.Diagnostics, Diagnostic{Item: itemPosition(n, diags), Title: ep.Title, Err: err})
+			}
+			ep.Podcast = p
+			// Channel persons apply to the episodes without their own.
+			if len(ep.Persons) == 0 {
+				ep.Persons = persons(c.Persons)
+			}
+			page.Episodes = append(page.Episodes, ep)
+		}
+		if page.Next == "" {
+			page.Next = nextLink(c.AtomLink)
+		}
+	}
+	return &page, nil
+}
+
+// rssChannel is the XML representation of a channel in a RSS feed.
+type rssChannel struct {
+	ItunesImage struct {
+		Href string `xml:"href,attr"`
+	} `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	ItunesAuthor string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+	ItunesTitle  string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+	AtomLink     []atomLink  `xml:"http://www.w3.org/2005/Atom link"`
+	Persons      []rssPerson `xml:"https://podcastindex.org/namespace/1.0 person"`
+
+	Title string `xml:"title"`
+	Link  string `xml:"link"`
+	Image struct {
+		URL string `xml:"url"`
+	} `xml:"image"`
+	ManagingEditor string    `xml:"managingEditor"`
+	Language       string    `xml:"language"`
+	Item           []rssItem `xml:"item"`
+}
+
+// podcast returns the show metadata contained in the channel.
+func (c rssChannel) podcast() *Podcast {
+	p := &Podcast{
+		Title:    strings.TrimSpace(c.Title),
+		Author:   strings.TrimSpace(c.ItunesAuthor),
+		Image:    strings.TrimSpace(c.ItunesImage.Href),
+		Language: strings.TrimSpace(c.Language),
+		Link:     strings.TrimSpace(c.Link),
+	}
+	if p.Title == "" {
+		p.Title = strings.TrimSpace(c.ItunesTitle)
+	}
+	if p.Author == "" {
+		p.Author = strings.TrimSpace(c.ManagingEditor)
+	}
+	if p.Image == "" {
+		p.Image = strings.TrimSpace(c.Image.URL)
+	}
+	return p
+}
+
+// rssItem is the XML representation of an item in a RSS feed.
+// Namespaced fields must come before the plain fields sharing their local
+// name, since encoding/xml assigns each element to the first field matching it.
+type rssItem struct {
+	ItunesTitle   string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+	ItunesEpisode string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	ItunesSeason  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd season"`
+	ItunesImage   struct {
+		Href string `xml:"href,attr"`
+	} `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	Duration       string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	EpisodeType    string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episodeType"`
+	Explicit       string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd explicit"`
+	PodcastEpisode string      `xml:"https://podcastindex.org/namespace/1.0 episode"`
+	PodcastSeason  string      `xml:"https://podcastindex.org/namespace/1.0 season"`
+	Persons        []rssPerson `xml:"https://podcastindex.org/namespace/1.0 person"`
+	Chapters       struct {
+		URL string `xml:"url,attr"`
+	} `xml:"https://podcastindex.org/namespace/1.0 chapters"`
+	Transcripts []struct {
+		URL      string `xml:"url,attr"`
+		Type     string `xml:"type,attr"`
+		Language string `xml:"language,attr"`
+		Rel      string `xml:"rel,attr"`
+	} `xml:"https://podcastindex.org/namespace/1.0 transcript"`
+	Alternate []struct {
+		Type    string  `xml:"type,attr"`
+		Length  int64   `xml:"length,attr"`
+		Bitrate float64 `xml:"bitrate,attr"`
+		Source  []struct {
+			URI string `xml:"uri,attr"`
+		} `xml:"https://podcastindex.org/namespace/1.0 source"`
+	} `xml:"https://podcastindex.org/namespace/1.0 alternateEnclosure"`
+	AtomLink []atomLink `xml:"http://www.w3.org/2005/Atom link"` // Keeps atom:link out of Link.
+
+	Title  string `xml:"title"`
+	Number string `xml:"order"`
+	Link   string `xml:"link"`
+	GUID   struct {
+		Value       string `xml:",chardata"`
+		IsPermaLink string `xml:"isPermaLink,attr"`
+	} `xml:"guid"`
+	Desc      string `xml:"summary"`
+	PubDate   string `xml:"pubDate"`
+	Enclosure []struct {
+		URL    string `xml:"url,attr"`
+		Type   string `xml:"type,attr"`
+		Length string `xml:"length,attr"`
+	} `xml:"enclosure"`
+	Category []string `xml:"category"`
+}
+
+// episode converts the item into an Episode, preferring the plain RSS
+// elements and falling back to their iTunes and Podcasting 2.0 equivalents.
+// The episode number is the exception: the namespaced elements are preferred
+// over the legacy order element, which most hosts no longer emit.
+// The error, if not nil, describes a problem with the publication date.
+func (i rssItem) episode() (Episode, error) {
+	published, dateErr := parseDate(i.PubDate)
+	ep := Episode{
+		ID:        strings.TrimSpace(i.GUID.Value),
+		Title:     i.Title,
+		Number:    firstInt(i.ItunesEpisode, i.PodcastEpisode),
+		Season:    firstInt(i.ItunesSeason, i.PodcastSeason),
+		Link:      strings.TrimSpace(i.Link),
+		Desc:      i.Desc,
+		Image:     strings.TrimSpace(i.ItunesImage.Href),
+		Published: published,
+		Duration:  parseDuration(i.Duration),
+		Type:      strings.ToLower(strings.TrimSpace(i.EpisodeType)),
+		Explicit:  parseExplicit(i.Explicit),
+		Tags:      i.Category,
+	}
+	if ep.Title == "" {
+		ep.Title = i.ItunesTitle
+	}
+	// A guid is a permalink unless explicitly stated otherwise.
+	if ep.Link == "" && i.GUID.IsPermaLink != "false" {
+		ep.Link = ep.ID
+	}
+	if ep.Number != 0 {
+		ep.NumberSource = NumberFromEpisode
+	} else if n := firstInt(i.Number); n > 0 {
+		ep.Number, ep.NumberSource = n, NumberFromOrder
+	}
+	if ep.Type == "" {
+		ep.Type = "full"
+	}
+	for _, e := range i.Enclosure {
+		// Some hosts leave the length empty or set it to a placeholder.
+		length, _ := strconv.ParseInt(strings.TrimSpace(e.Length), 10, 64)
+		ep.Enclosures = append(ep.Enclosures, newEnclosure(e.URL, e.Type, length, 0))
+	}
+	for _, a := range i.Alternate {
+		for _, s := range a.Source {
+			ep.Enclosures = append(ep.Enclosures, newEnclosure(s.URI, a.Type, a.Length, int(a.Bitrate)))
+		}
+	}
+	ep.ChaptersURL = strings.TrimSpace(i.Chapters.URL)
+	for _, t := range i.Transcripts {
+		ep.Transcripts = append(ep.Transcripts, Transcript{
+			URL:      strings.TrimSpace(t.URL),
+			Type:     strings.ToLower(strings.TrimSpace(t.Type)),
+			Language: strings.TrimSpace(t.Language),
+			Rel:      t.Rel,
+		})
+	}
+	ep.Persons = persons(i.Persons)
+	return ep, dateErr
+}
+
+// rssPerson is the XML representation of a podcast:person element.
+type rssPerson struct {
+	Name  string `xml:",chardata"`
+	Role  string `xml:"role,attr"`
+	Group string `xml:"group,attr"`
+	Img   string `xml:"img,attr"`
+	Href  string `xml:"href,attr"`
+}
+
+// persons converts podcast:person elements into persons. As specified in the
+// Podcasting 2.0 namespace, the default role is "host" in the "cast" group.
+func persons(ps []rssPerson) []Person {
+	var res []Person
+	for _, p := range ps {
+		person := Person{
+			Name:  strings.TrimSpace(p.Name),
+			Role:  strings.ToLower(strings.TrimSpace(p.Role)),
+			Group: strings.ToLower(strings.TrimSpace(p.Group)),
+			Image: strings.TrimSpace(p.Img),
+			Href:  strings.TrimSpace(p.Href),
+		}
+		if person.Role == "" {
+			person.Role = "host"
+		}
+		if person.Group == "" {
+			person.Group = "cast"
+		}
+		res = append(res, person)
+	}
+	return res
+}
@@ -0,0 +1,209 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podcast
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Transcript is a Podcasting 2.0 transcript of an episode.
+type Transcript struct {
+	URL      string
+	Type     string // MIME type, such as "text/vtt" or "application/x-subrip".
+	Language string // Language code, such as "en"; empty if unknown.
+	Rel      string // "captions" if the transcript contains timed captions.
+	Cues     []Cue  // Filled by Fetcher.FetchTranscript.
+}
+
+// A Cue is a timed segment of a transcript.
+type Cue struct {
+	Start   time.Duration
+	End     time.Duration // Zero if unknown.
+	Speaker string        // Empty if unknown.
+	Text    string
+}
+
+// FetchTranscript fetches and parses the given transcript, storing its cues
+// in it. SubRip (SRT), WebVTT, and Podcast Index JSON transcripts are
+// supported.
+func (f *Fetcher) FetchTranscript(ctx context.Context, t *Transcript) error {
+	data, err := f.get(ctx, t.URL)
+	if err != nil {
+		return err
+	}
+	cues, err := ParseTranscript(data, t.Type)
+	if err != nil {
+		return fmt.Errorf("could not parse transcript %s: %w", t.URL, err)
+	}
+	t.Cues = cues
+	return nil
+}
+
+// ParseTranscript parses a transcript of the given MIME type. SubRip (SRT),
+// WebVTT, and Podcast Index JSON transcripts are supported.
+func ParseTranscript(data []byte, typ string) ([]Cue, error) {
+	switch typ {
+	case "application/x-subrip", "application/srt", "text/srt", "text/vtt":
+		return parseTimedText(data)
+	case "application/json":
+		return parseJSONTranscript(data)
+	default:
+		return nil, fmt.Errorf("unsupported transcript type %q", typ)
+	}
+}
+
+// parseJSONTranscript parses a Podcast Index JSON transcript.
+func parseJSONTranscript(data []byte) ([]Cue, error) {
+	var doc struct {
+		Segments []struct {
+			Speaker   string  `json:"speaker"`
+			StartTime float64 `json:"startTime"`
+			EndTime   float64 `json:"endTime"`
+			Body      string  `json:"body"`
+		} `json:"segments"`
+	}
+	if err := decodeJSON(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var cues []Cue
+	for _, s := range doc.Segments {
+		cues = append(cues, Cue{
+			Start:   seconds(s.StartTime),
+			End:     seconds(s.EndTime),
+			Speaker: s.Speaker,
+			Text:    strings.TrimSpace(s.Body),
+		})
+	}
+	return cues, nil
+}
+
+var (
+	voiceRE = regexp.MustCompile(`^<v(?:\.[^ >]*)? ([^>]*)>`)
+	tagRE   = regexp.MustCompile(`</?[^>]+>`)
+)
+
+// parseTimedText parses SubRip and WebVTT documents, which share the same
+// structure: blocks separated by blank lines, each with a timing line such
+// as "00:00:01,000 --> 00:00:04,500" followed by the text of the cue.
+// WebVTT blocks without timings, such as the header or notes, are ignored.
+func parseTimedText(data []byte) ([]Cue, error) {
+	var cues []Cue
+	var cue *Cue
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case line == "":
+			cue = nil
+		case strings.Contains(line, "-->"):
+			start, end, err := parseTiming(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+			cues = append(cues, Cue{Start: start, End: end})
+			cue = &cues[len(cues)-1]
+		case cue != nil:
+			if m := voiceRE.FindStringSubmatch(line); m != nil && cue.Text == "" {
+				cue.Speaker = m[1]
+			}
+			line = tagRE.ReplaceAllString(line, "")
+			if cue.Text != "" {
+				line = "\n" + line
+			}
+			cue.Text += line
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return cues, nil
+}
+
+// parseTiming parses a cue timing line, ignoring any WebVTT cue settings
+// after the end timestamp.
+func parseTiming(line string) (start, end time.Duration, err error) {
+	ps := strings.SplitN(line, "-->", 2)
+	if start, err = parseTimestamp(ps[0]); err != nil {
+		return 0, 0, err
+	}
+	fs := strings.Fields(ps[1])
+	if len(fs) == 0 {
+		return 0, 0, fmt.Errorf("missing end timestamp in %q", line)
+	}
+	if end, err = parseTimestamp(fs[0]); err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// parseTimestamp parses a timestamp formatted as hh:mm:ss,ttt (SubRip) or
+// as hh:mm:ss.ttt or mm:ss.ttt (WebVTT).
+func parseTimestamp(s string) (time.Duration, error) {
+	s = strings.Replace(strings.TrimSpace(s), ",", ".", 1)
+	var d time.Duration
+	ps := strings.Split(s, ":")
+	if len(ps) < 2 || len(ps) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	for _, p := range ps {
+		n, err := strconv.ParseFloat(p, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		d = 60*d + seconds(n)
+	}
+	return d, nil
+}
+
+// defaultCueLength is the duration given to cues with no end time and no cue
+// after them.
+const defaultCueLength = 3 * time.Second
+
+// FormatSRT formats cues as a SubRip (SRT) document. The speaker of a cue,
+// if known, is written before its text when it differs from the previous one.
+// Cues without an end last until the next one starts, or defaultCueLength.
+func FormatSRT(cues []Cue) []byte {
+	var buf bytes.Buffer
+	speaker := ""
+	for i, c := range cues {
+		text := c.Text
+		if c.Speaker != "" && c.Speaker != speaker {
+			text = c.Speaker + ": " + text
+		}
+		speaker = c.Speaker
+		end := c.End
+		if end <= c.Start {
+			end = c.Start + defaultCueLength
+			if i+1 < len(cues) && cues[i+1].Start > c.Start && cues[i+1].Start < end {
+				end = cues[i+1].Start
+			}
+		}
+		fmt.Fprintf(&buf, "%d\n%s --> %s\n%s\n\n", i+1, srtTimestamp(c.Start), srtTimestamp(end), text)
+	}
+	return buf.Bytes()
+}
+
+// srtTimestamp formats a duration as hh:mm:ss,ttt.
+func srtTimestamp(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,263 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+	"github.com/campoy/podcast-to-youtube/state"
+	"github.com/campoy/podcast-to-youtube/youtube"
+)
+
+// The problems reported by the reconcile command.
+const (
+	problemMissing    = "missing"      // An episode has no video in the playlist.
+	problemDuplicate  = "duplicate"    // An episode has more than one video in the playlist.
+	problemOrphaned   = "orphaned"     // A video in the playlist matches no episode.
+	problemPrivate    = "private"      // A video in the playlist is private.
+	problemDeleted    = "deleted"      // A video in the playlist was deleted.
+	problemOutOfOrder = "out-of-order" // A video is not where its episode belongs.
+)
+
+// A discrepancy is a difference between a feed and its playlist, found by
+// the reconcile command.
+type discrepancy struct {
+	Feed     string `json:"feed"`
+	Problem  string `json:"problem"`
+	Episode  string `json:"episode,omitempty"` // ID of the episode.
+	Number   int    `json:"number,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Video    string `json:"video,omitempty"`
+	Position *int64 `json:"position,omitempty"` // Position of the video in the playlist.
+	Detail   string `json:"detail,omitempty"`
+	Fixed    bool   `json:"fixed,omitempty"`
+}
+
+// runReconcile runs the reconcile command with the given arguments over the
+// given shows, and returns the exit code of the command: 0 if the feeds and
+// playlists match, 1 if discrepancies remain, and 2 if the command failed.
+func runReconcile(client *youtube.Client, fetcher *podcast.Fetcher, store *state.Store, shows []show, args []string) int {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	jsonOut := fs.Bool("json", false, "Print the discrepancies found as a JSON array")
+	fix := fs.Bool("fix", false, "Add the missing episodes with an uploaded video to the playlist")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	code := 0
+	ds := []discrepancy{}
+	for _, s := range shows {
+		found, err := reconcileShow(context.Background(), client, fetcher, store, s, *fix)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", s.feed, err)
+			code = 2
+			continue
+		}
+		ds = append(ds, found...)
+	}
+	for _, d := range ds {
+		if !d.Fixed && code == 0 {
+			code = 1
+		}
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(ds); err != nil {
+			fmt.Fprintf(os.Stderr, "could not encode discrepancies: %v\n", err)
+			return 2
+		}
+		return code
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FEED\tPROBLEM\tEPISODE\tVIDEO\tPOSITION\tDETAIL")
+	for _, d := range ds {
+		ep, pos, detail := "", "", d.Detail
+		if d.Episode != "" {
+			ep = fmt.Sprintf("#%d %s", d.Number, d.Title)
+		}
+		if d.Position != nil {
+			pos = fmt.Sprint(*d.Position)
+		}
+		if d.Fixed {
+			detail = "fixed: " + detail
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", d.Feed, d.Problem, ep, d.Video, pos, detail)
+	}
+	if err := w.Flush(); err != nil {
+		return 2
+	}
+	return code
+}
+
+// reconcileShow compares the feed and the playlist of the given show and, if
+// fix is true, adds the missing episodes with an uploaded video to it.
+func reconcileShow(ctx context.Context, client *youtube.Client, fetcher *podcast.Fetcher, store *state.Store, s show, fix bool) ([]discrepancy, error) {
+	eps, err := fetcher.Fetch(ctx, s.feed)
+	if err != nil {
+		return nil, err
+	}
+	items, err := client.PlaylistItems(ctx, s.playlist)
+	if err != nil {
+		return nil, err
+	}
+
+	ds := reconcile(s, eps, items, store)
+	if !fix {
+		return ds, nil
+	}
+	for i, d := range ds {
+		if d.Problem != problemMissing || d.Video == "" {
+			continue
+		}
+		if err := client.AddToPlaylist(s.playlist, d.Video); err != nil {
+			ds[i].Detail = fmt.Sprintf("could not add video to the playlist: %v", err)
+			continue
+		}
+		rec, _ := store.Get(d.Episode)
+		rec.Status = state.StatusPublished
+		if err := store.Put(rec); err != nil {
+			return nil, err
+		}
+		ds[i].Fixed = true
+	}
+	return ds, nil
+}
+
+// reconcile returns the discrepancies between the given episodes of a show
+// and the items of its playlist. The videos of missing episodes are looked
+// up in the store.
+func reconcile(s show, eps []podcast.Episode, items []youtube.PlaylistItem, store *state.Store) []discrepancy {
+	var ds []discrepancy
+	add := func(problem string, ep *podcast.Episode, item *youtube.PlaylistItem, format string, args ...interface{}) {
+		d := discrepancy{Feed: s.feed, Problem: problem, Detail: fmt.Sprintf(format, args...)}
+		if ep != nil {
+			d.Episode, d.Number, d.Title = ep.ID, ep.Number, ep.Title
+		}
+		if item != nil {
+			pos := item.Position
+			d.Video, d.Position = item.VideoID, &pos
+		}
+		ds = append(ds, d)
+	}
+
+	// Deleted and private videos are reported on their own, and not as
+	// orphaned, since YouTube hides their details.
+	var live []youtube.PlaylistItem
+	for i, item := range items {
+		switch {
+		case item.Title == "Deleted video" || item.Privacy == "privacyStatusUnspecified":
+			add(problemDeleted, nil, &items[i], "video is no longer available")
+		case item.Privacy == "private":
+			add(problemPrivate, nil, &items[i], "%q is private", item.Title)
+			live = append(live, item)
+		default:
+			live = append(live, item)
+		}
+	}
+	ix := indexPlaylist(live, eps)
+
+	for _, item := range ix.unmatched {
+		if item.Privacy != "private" {
+			add(problemOrphaned, nil, &item, "%q matches no episode in the feed", item.Title)
+		}
+	}
+
+	for i, ep := range eps {
+		vids := ix.videos[ep.ID]
+		for j := 1; j < len(vids); j++ {
+			add(problemDuplicate, &eps[i], &vids[j], "also published as video %s", vids[0].VideoID)
+		}
+		if len(vids) > 0 {
+			continue
+		}
+		rec, ok := store.Get(ep.ID)
+		if !ok || rec.Playlist != s.playlist || rec.VideoID == "" {
+			add(problemMissing, &eps[i], nil, "never uploaded")
+			continue
+		}
+		if rec.Status == state.StatusFailed {
+			add(problemMissing, &eps[i], nil, "video %s failed: %s", rec.VideoID, rec.Reason)
+			continue
+		}
+		d := len(ds)
+		add(problemMissing, &eps[i], nil, "uploaded but not in the playlist")
+		ds[d].Video = rec.VideoID
+	}
+
+	for _, i := range outOfOrder(ix, eps) {
+		item := ix.videos[eps[i].ID][0]
+		add(problemOutOfOrder, &eps[i], &item, "video is out of order")
+	}
+	return ds
+}
+
+// outOfOrder returns the indexes of the given episodes whose first video needs
+// to move for the playlist to be sorted as requested by -sort-playlist or, if
+// empty, to follow the order of the episodes, either oldest or newest first,
+// whichever needs the fewest moves.
+func outOfOrder(ix playlistIndex, eps []podcast.Episode) []int {
+	var targets [][]string
+	if *sortPlaylist != "" {
+		sorted, err := sortEpisodes(eps, *sortPlaylist)
+		if err != nil {
+			return nil
+		}
+		targets = append(targets, ix.firstVideos(sorted))
+	} else {
+		asc := ix.firstVideos(eps)
+		desc := make([]string, len(asc))
+		for i, id := range asc {
+			desc[len(asc)-1-i] = id
+		}
+		targets = append(targets, asc, desc)
+	}
+
+	// The first videos of the episodes, in playlist order.
+	epByItem := make(map[string]int)
+	var items []youtube.PlaylistItem
+	for i, ep := range eps {
+		if v := ix.videos[ep.ID]; len(v) > 0 {
+			epByItem[v[0].ID] = i
+			items = append(items, v[0])
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Position < items[j].Position })
+	current := make([]string, len(items))
+	for i, item := range items {
+		current[i] = item.ID
+	}
+
+	var moves []youtube.Move
+	for i, target := range targets {
+		if m := youtube.PlanMoves(current, target); i == 0 || len(m) < len(moves) {
+			moves = m
+		}
+	}
+	var res []int
+	for _, m := range moves {
+		res = append(res, epByItem[m.ItemID])
+	}
+	sort.Ints(res)
+	return res
+}
@@ -0,0 +1,69 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+	"github.com/campoy/podcast-to-youtube/state"
+	"github.com/campoy/podcast-to-youtube/youtube"
+)
+
+func TestReconcile(t *testing.T) {
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(state.Record{EpisodeID: "e", Playlist: "p", VideoID: "ve", Status: state.StatusProcessed}); err != nil {
+		t.Fatal(err)
+	}
+
+	var eps []podcast.Episode
+	for i, id := range []string{"a", "b", "c", "d", "e", "f"} {
+		eps = append(eps, podcast.Episode{ID: id, Number: i + 1, Title: "Episode " + id})
+	}
+	item := func(video, id string, pos int64) youtube.PlaylistItem {
+		return youtube.PlaylistItem{ID: video, VideoID: video, Title: video, Description: "Episode ID: " + id, Position: pos, Privacy: "public"}
+	}
+	items := []youtube.PlaylistItem{
+		item("va", "a", 0),
+		item("vc", "c", 1),
+		item("vb", "b", 2),
+		item("vd", "d", 3),
+		item("vb2", "b", 4),
+		{ID: "vx", VideoID: "vx", Title: "Deleted video", Position: 5, Privacy: "privacyStatusUnspecified"},
+		{ID: "vy", VideoID: "vy", Title: "Behind the scenes", Position: 6, Privacy: "public"},
+		{ID: "vz", VideoID: "vz", Title: "Private video", Position: 7, Privacy: "private"},
+	}
+
+	var got []string
+	for _, d := range reconcile(show{feed: "feed", playlist: "p"}, eps, items, store) {
+		got = append(got, d.Problem+" "+d.Episode+" "+d.Video)
+	}
+	want := []string{
+		"deleted  vx",
+		"private  vz",
+		"orphaned  vy",
+		"duplicate b vb2",
+		"missing e ve",
+		"missing f ",
+		"out-of-order c vc",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected discrepancies:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
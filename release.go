@@ -0,0 +1,111 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	// Release policies can name any time zone, even if the system has no
+	// time zone database.
+	_ "time/tzdata"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+)
+
+// A releasePolicy returns when the video of an episode should be made public,
+// given the current time, or the zero time to make it public on upload.
+type releasePolicy func(ep podcast.Episode, now time.Time) time.Time
+
+// parseRelease parses a release policy, which is one of:
+//
+//	now                              release videos once uploaded; also the empty string
+//	pubdate                          release videos at the publication date of their episode
+//	[next] [weekday|daily] HH:MM [zone]  release videos at the first such time after the publication date
+//
+// For instance, "next Tuesday 09:00 Europe/Madrid" releases every video on
+// the first Tuesday at 9am in Madrid after its episode was published, or after
+// it is uploaded, whichever comes later. The default time zone is the local one.
+func parseRelease(s string) (releasePolicy, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) == 1 && fields[0] == "now" {
+		return func(podcast.Episode, time.Time) time.Time { return time.Time{} }, nil
+	}
+	if len(fields) == 1 && fields[0] == "pubdate" {
+		return func(ep podcast.Episode, now time.Time) time.Time {
+			if ep.Published.After(now) {
+				return ep.Published
+			}
+			return time.Time{}
+		}, nil
+	}
+
+	if strings.EqualFold(fields[0], "next") {
+		fields = fields[1:]
+	}
+	weekday := -1
+	if len(fields) > 0 {
+		if strings.EqualFold(fields[0], "daily") {
+			fields = fields[1:]
+		} else if d, ok := parseWeekday(fields[0]); ok {
+			weekday = int(d)
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("missing release time")
+	}
+	hm, err := time.Parse("15:04", fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("release time should be HH:MM; got %q", fields[0])
+	}
+	loc := time.Local
+	switch len(fields) {
+	case 1:
+	case 2:
+		if loc, err = time.LoadLocation(fields[1]); err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", fields[1])
+		}
+	default:
+		return nil, fmt.Errorf("unexpected %q after time zone", strings.Join(fields[2:], " "))
+	}
+
+	return func(ep podcast.Episode, now time.Time) time.Time {
+		after := now
+		if ep.Published.After(after) {
+			after = ep.Published
+		}
+		after = after.In(loc)
+		// We build every candidate with time.Date so the time of day is kept
+		// across daylight saving time changes.
+		for d := 0; ; d++ {
+			t := time.Date(after.Year(), after.Month(), after.Day()+d, hm.Hour(), hm.Minute(), 0, 0, loc)
+			if t.After(after) && (weekday < 0 || int(t.Weekday()) == weekday) {
+				return t
+			}
+		}
+	}, nil
+}
+
+// parseWeekday parses the full or abbreviated English name of a weekday.
+func parseWeekday(s string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(s, d.String()) || strings.EqualFold(s, d.String()[:3]) {
+			return d, true
+		}
+	}
+	return 0, false
+}
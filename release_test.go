@@ -0,0 +1,63 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+)
+
+func TestParseRelease(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Thursday, 10:00 UTC.
+	now := time.Date(2024, 3, 28, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		policy    string
+		published time.Time
+		want      time.Time
+	}{
+		{"", now.Add(time.Hour), time.Time{}},
+		{"now", now.Add(time.Hour), time.Time{}},
+		{"pubdate", now.Add(-time.Hour), time.Time{}},
+		{"pubdate", now.Add(time.Hour), now.Add(time.Hour)},
+		// The clocks change in Madrid on Sunday, March 31.
+		{"next Tuesday 09:00 Europe/Madrid", now, time.Date(2024, 4, 2, 9, 0, 0, 0, madrid)},
+		{"tue 09:00 Europe/Madrid", now.AddDate(0, 0, 5), time.Date(2024, 4, 9, 9, 0, 0, 0, madrid)},
+		{"daily 11:30 UTC", now, time.Date(2024, 3, 28, 11, 30, 0, 0, time.UTC)},
+		{"09:00 UTC", now, time.Date(2024, 3, 29, 9, 0, 0, 0, time.UTC)},
+		{"thursday 10:00 UTC", now, time.Date(2024, 4, 4, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		release, err := parseRelease(tt.policy)
+		if err != nil {
+			t.Errorf("could not parse %q: %v", tt.policy, err)
+			continue
+		}
+		if got := release(podcast.Episode{Published: tt.published}, now); !got.Equal(tt.want) {
+			t.Errorf("%q released episode published at %v at %v; expected %v", tt.policy, tt.published, got, tt.want)
+		}
+	}
+
+	for _, policy := range []string{"next Tuesday", "Tuesday 9am", "daily 09:00 Mars/Olympus", "09:00 UTC later"} {
+		if _, err := parseRelease(policy); err == nil {
+			t.Errorf("expected error parsing %q", policy)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+)
+
+// A selector reports whether an episode should be published.
+type selector func(ep podcast.Episode) bool
+
+// parseSelector parses an episode selection expression, made of whitespace
+// separated terms that must all match an episode for it to be selected:
+//
+//	1-10,15               episode numbers, as a comma separated list of ranges
+//	season:2              seasons, as a comma separated list of ranges
+//	since:2024-01-01      episodes published on or after the given date
+//	until:2024-12-31      episodes published on or before the given date
+//	category:go,cloud     episodes with any of the given categories
+//	title:(?i)interview   episodes whose title matches the regular expression
+//	all                   every episode
+//
+// Dates are given as YYYY-MM-DD in UTC, or in RFC 3339 format.
+func parseSelector(expr string) (selector, error) {
+	terms := strings.Fields(expr)
+	if len(terms) == 0 {
+		return nil, errors.New("empty episode selector")
+	}
+
+	var sels []selector
+	for _, term := range terms {
+		sel, err := parseTerm(term)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %v", term, err)
+		}
+		sels = append(sels, sel)
+	}
+	return func(ep podcast.Episode) bool {
+		for _, sel := range sels {
+			if !sel(ep) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// parseTerm parses a single term of an episode selection expression.
+func parseTerm(term string) (selector, error) {
+	if term == "all" {
+		return func(podcast.Episode) bool { return true }, nil
+	}
+
+	key, value := "", term
+	if i := strings.Index(term, ":"); i >= 0 {
+		key, value = term[:i], term[i+1:]
+	}
+
+	switch key {
+	case "":
+		in, err := parseRanges(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(ep podcast.Episode) bool { return in(ep.Number) }, nil
+	case "season":
+		in, err := parseRanges(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(ep podcast.Episode) bool { return in(ep.Season) }, nil
+	case "since":
+		t, _, err := parseDay(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(ep podcast.Episode) bool { return !ep.Published.Before(t) }, nil
+	case "until":
+		t, day, err := parseDay(value)
+		if err != nil {
+			return nil, err
+		}
+		// A date includes the whole day.
+		if day {
+			t = t.AddDate(0, 0, 1)
+			return func(ep podcast.Episode) bool { return ep.Published.Before(t) }, nil
+		}
+		return func(ep podcast.Episode) bool { return !ep.Published.After(t) }, nil
+	case "category":
+		cats := strings.Split(value, ",")
+		return func(ep podcast.Episode) bool {
+			for _, tag := range ep.Tags {
+				for _, c := range cats {
+					if strings.EqualFold(strings.TrimSpace(tag), c) {
+						return true
+					}
+				}
+			}
+			return false
+		}, nil
+	case "title":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(ep podcast.Episode) bool { return re.MatchString(ep.Title) }, nil
+	default:
+		return nil, fmt.Errorf("unknown selector %q", key)
+	}
+}
+
+// parseRanges parses a comma separated list of ranges, as accepted by
+// parseRange, and returns a function reporting whether a number is in any
+// of them.
+func parseRanges(s string) (func(n int) bool, error) {
+	type span struct{ first, last int }
+	var spans []span
+	for _, r := range strings.Split(s, ",") {
+		first, last, err := parseRange(r)
+		if err != nil {
+			return nil, err
+		}
+		if first > last {
+			return nil, fmt.Errorf("range %s is empty", r)
+		}
+		spans = append(spans, span{first, last})
+	}
+	return func(n int) bool {
+		for _, s := range spans {
+			if s.first <= n && n <= s.last {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// parseRange parses either a range (n-m) or a single episode number (n)
+// and returns the first and last elements of the range.
+func parseRange(s string) (first, last int, err error) {
+	switch ps := strings.Split(s, "-"); len(ps) {
+	case 1:
+		n, err := strconv.Atoi(ps[0])
+		return n, n, err
+	case 2:
+		from, err := strconv.Atoi(ps[0])
+		if err != nil {
+			return 0, 0, err
+		}
+		to, err := strconv.Atoi(ps[1])
+		return from, to, err
+	default:
+		return 0, 0, errors.New("only formats supported are n or m-n")
+	}
+}
+
+// parseDay parses a date as YYYY-MM-DD in UTC, or a time in RFC 3339 format,
+// and reports whether it was a date.
+func parseDay(s string) (t time.Time, day bool, err error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, false, errors.New("dates should be YYYY-MM-DD or RFC 3339")
+	}
+	return t, false, nil
+}
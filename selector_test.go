@@ -0,0 +1,72 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+)
+
+func TestParseSelector(t *testing.T) {
+	day := func(s string) time.Time {
+		t, _ := time.Parse(time.RFC3339, s)
+		return t
+	}
+	eps := []podcast.Episode{
+		{Number: 1, Season: 1, Title: "Welcome", Published: day("2023-12-31T10:00:00Z"), Tags: []string{"News"}},
+		{Number: 2, Season: 1, Title: "Interview with Ada", Published: day("2024-01-01T00:00:00Z")},
+		{Number: 3, Season: 2, Title: "An interview", Published: day("2024-06-30T23:59:00Z"), Tags: []string{"Go", "cloud"}},
+		{Number: 4, Season: 2, Title: "Wrap up", Published: day("2024-07-01T00:00:00Z")},
+	}
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"all", "1234"},
+		{"2", "2"},
+		{"1-2,4", "124"},
+		{"season:2", "34"},
+		{"since:2024-01-01", "234"},
+		{"until:2024-06-30", "123"},
+		{"since:2024-01-01 until:2024-06-30T12:00:00Z", "2"},
+		{"category:go,news", "13"},
+		{"title:(?i)interview", "23"},
+		{"title:(?i)interview season:1", "2"},
+	}
+	for _, tt := range tests {
+		sel, err := parseSelector(tt.expr)
+		if err != nil {
+			t.Errorf("could not parse %q: %v", tt.expr, err)
+			continue
+		}
+		got := ""
+		for _, ep := range eps {
+			if sel(ep) {
+				got += string(rune('0' + ep.Number))
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%q selected episodes %q; expected %q", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, expr := range []string{"", "a-b", "3-1", "since:yesterday", "title:(", "tag:go"} {
+		if _, err := parseSelector(expr); err == nil {
+			t.Errorf("expected error parsing %q", expr)
+		}
+	}
+}
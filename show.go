@@ -0,0 +1,158 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/campoy/podcast-to-youtube/podcast"
+	"github.com/campoy/podcast-to-youtube/youtube"
+)
+
+// A show contains the settings used to publish the episodes of a podcast.
+type show struct {
+	feed       string
+	playlist   string
+	logo       string
+	title      *template.Template
+	foreground color.Color
+	background color.Color
+	tags       []string
+	privacy    string
+	release    releasePolicy
+	metadata   metadataConfig
+}
+
+// defaultShow returns the show described by the command line flags.
+func defaultShow() show {
+	return show{
+		feed:       *rssFeed,
+		playlist:   *playlist,
+		logo:       *logo,
+		title:      titleTmpl,
+		foreground: foreground,
+		background: background,
+		tags:       strings.Split(*tags, ","),
+		privacy:    *privacy,
+		release:    release,
+		metadata:   metadata,
+	}
+}
+
+// videoTitle returns the title of the video for the given episode.
+func (s show) videoTitle(ep podcast.Episode) (string, error) {
+	var buf bytes.Buffer
+	if err := s.title.Execute(&buf, ep); err != nil {
+		return "", fmt.Errorf("could not create video title from template: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// loadShows returns the shows listed in the given OPML file. The settings of
+// each show are taken from the attributes of its outline, falling back to the
+// command line flags. The supported attributes are playlist, titleTemplate,
+// fg, bg, logo, tags, privacy, publishAt, and metadata, the path of a video
+// metadata file replacing the one given with -metadata.
+func loadShows(path string) ([]show, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	outlines, err := podcast.ParseOPML(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", path, err)
+	}
+
+	var shows []show
+	for _, o := range outlines {
+		s := defaultShow()
+		s.feed = o.URL
+		if v := o.Attrs["playlist"]; v != "" {
+			s.playlist = v
+		}
+		if v := o.Attrs["privacy"]; v != "" {
+			if err := checkPrivacy(v); err != nil {
+				return nil, fmt.Errorf("invalid privacy for %s: %v", o.URL, err)
+			}
+			s.privacy = v
+		}
+		if v := o.Attrs["publishAt"]; v != "" {
+			if s.release, err = parseRelease(v); err != nil {
+				return nil, fmt.Errorf("invalid release policy for %s: %v", o.URL, err)
+			}
+		}
+		if v := o.Attrs["metadata"]; v != "" {
+			if s.metadata, err = loadMetadata(v); err != nil {
+				return nil, err
+			}
+		}
+		if v := o.Attrs["logo"]; v != "" {
+			s.logo = v
+		}
+		if v := o.Attrs["tags"]; v != "" {
+			s.tags = strings.Split(v, ",")
+		}
+		if v := o.Attrs["titleTemplate"]; v != "" {
+			if s.title, err = template.New("title").Parse(v); err != nil {
+				return nil, fmt.Errorf("invalid title template for %s: %v", o.URL, err)
+			}
+		}
+		if v := o.Attrs["fg"]; v != "" {
+			if s.foreground, err = parseHexColor(v); err != nil {
+				return nil, fmt.Errorf("invalid foreground color for %s: %v", o.URL, err)
+			}
+		}
+		if v := o.Attrs["bg"]; v != "" {
+			if s.background, err = parseHexColor(v); err != nil {
+				return nil, fmt.Errorf("invalid background color for %s: %v", o.URL, err)
+			}
+		}
+		shows = append(shows, s)
+	}
+	if len(shows) == 0 {
+		return nil, fmt.Errorf("no feeds found in %s", path)
+	}
+	return shows, nil
+}
+
+// checkPrivacy checks that the given privacy status is valid.
+func checkPrivacy(privacy string) error {
+	switch privacy {
+	case youtube.Public, youtube.Unlisted, youtube.Private:
+		return nil
+	}
+	return fmt.Errorf("unknown privacy status %q; use public, unlisted, or private", privacy)
+}
+
+// parseHexColor parses a color encoded as six hexadecimal digits, optionally
+// preceded by #, like the ones accepted by the -fg and -bg flags.
+func parseHexColor(s string) (color.Color, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return nil, fmt.Errorf("color should be 6 digits")
+	}
+	n, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("not hexadecimal: %v", err)
+	}
+	return color.RGBA{uint8(n >> 16), uint8(n >> 8), uint8(n), 255}, nil
+}
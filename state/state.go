@@ -0,0 +1,169 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package state provides a ledger recording which podcast episodes have been
+// published to YouTube, stored as a single JSON file.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+)
+
+// version is the version of the ledger file format.
+const version = 1
+
+// Status is the publication status of an episode.
+type Status string
+
+// The statuses an episode goes through while being published.
+const (
+	StatusUploaded  Status = "uploaded"  // The video was uploaded.
+	StatusProcessed Status = "processed" // YouTube finished processing the video.
+	StatusPublished Status = "published" // The video was added to the playlist.
+	StatusFailed    Status = "failed"    // YouTube could not process the video.
+)
+
+// A Record describes the video published for an episode.
+type Record struct {
+	EpisodeID string    `json:"episodeId"`
+	Playlist  string    `json:"playlist"`
+	VideoID   string    `json:"videoId"`
+	Uploaded  time.Time `json:"uploaded"`
+	Status    Status    `json:"status"`
+	AudioHash string    `json:"audioHash,omitempty"` // Hex encoded SHA-256 of the episode media.
+	Reason    string    `json:"reason,omitempty"`    // Why the video failed, if it did.
+}
+
+// A Store is a ledger of published episodes, backed by a JSON file.
+// Every change is written to the file immediately.
+type Store struct {
+	path    string
+	records map[string]Record // By episode ID.
+}
+
+// file is the JSON representation of a ledger.
+type file struct {
+	Version  int      `json:"version"`
+	Episodes []Record `json:"episodes"`
+}
+
+// Open opens the ledger at the given path. A missing file is an empty ledger.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, records: make(map[string]Record)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read state: %v", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("could not decode %s: %v", path, err)
+	}
+	if f.Version != version {
+		return nil, fmt.Errorf("unsupported version %d of %s", f.Version, path)
+	}
+	for _, r := range f.Episodes {
+		s.records[r.EpisodeID] = r
+	}
+	return s, nil
+}
+
+// Get returns the record of the episode with the given ID, if any.
+func (s *Store) Get(episodeID string) (Record, bool) {
+	r, ok := s.records[episodeID]
+	return r, ok
+}
+
+// Published reports whether the episode with the given ID was published.
+func (s *Store) Published(episodeID string) bool {
+	r, ok := s.records[episodeID]
+	return ok && r.Status == StatusPublished
+}
+
+// Failed reports whether YouTube could not process the video uploaded for
+// the episode with the given ID.
+func (s *Store) Failed(episodeID string) bool {
+	r, ok := s.records[episodeID]
+	return ok && r.Status == StatusFailed
+}
+
+// Playlist returns the records of the episodes in the given playlist, sorted
+// by upload time.
+func (s *Store) Playlist(playlist string) []Record {
+	var rs []Record
+	for _, r := range s.records {
+		if r.Playlist == playlist {
+			rs = append(rs, r)
+		}
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		if !rs[i].Uploaded.Equal(rs[j].Uploaded) {
+			return rs[i].Uploaded.Before(rs[j].Uploaded)
+		}
+		return rs[i].EpisodeID < rs[j].EpisodeID
+	})
+	return rs
+}
+
+// Put adds or replaces the record of an episode and saves the ledger.
+func (s *Store) Put(r Record) error {
+	s.records[r.EpisodeID] = r
+	return s.save()
+}
+
+// Reset replaces all the records of the given playlist and saves the ledger.
+func (s *Store) Reset(playlist string, rs []Record) error {
+	for id, r := range s.records {
+		if r.Playlist == playlist {
+			delete(s.records, id)
+		}
+	}
+	for _, r := range rs {
+		r.Playlist = playlist
+		s.records[r.EpisodeID] = r
+	}
+	return s.save()
+}
+
+// save writes the ledger to its file.
+func (s *Store) save() error {
+	f := file{Version: version, Episodes: []Record{}}
+	for _, r := range s.records {
+		f.Episodes = append(f.Episodes, r)
+	}
+	sort.Slice(f.Episodes, func(i, j int) bool { return f.Episodes[i].EpisodeID < f.Episodes[j].EpisodeID })
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode state: %v", err)
+	}
+
+	// We write to a temporary file first so an interrupted run never leaves
+	// a partially written ledger.
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("could not write state: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("could not write state: %v", err)
+	}
+	return nil
+}
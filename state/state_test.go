@@ -0,0 +1,75 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("could not open missing ledger: %v", err)
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []Record{
+		{EpisodeID: "a", Playlist: "p", VideoID: "va", Uploaded: now, Status: StatusPublished, AudioHash: "1234"},
+		{EpisodeID: "b", Playlist: "p", VideoID: "vb", Uploaded: now.Add(time.Hour), Status: StatusUploaded},
+		{EpisodeID: "c", Playlist: "q", VideoID: "vc", Uploaded: now, Status: StatusPublished},
+		{EpisodeID: "e", Playlist: "q", VideoID: "ve", Uploaded: now, Status: StatusFailed, Reason: "duplicate"},
+	}
+	for _, r := range records {
+		if err := s.Put(r); err != nil {
+			t.Fatalf("could not put record: %v", err)
+		}
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatalf("could not reopen ledger: %v", err)
+	}
+	if r, ok := s.Get("a"); !ok || r != records[0] {
+		t.Errorf("expected record %+v; got %+v", records[0], r)
+	}
+	if !s.Published("a") || s.Published("b") || s.Published("z") {
+		t.Errorf("only a and c should be published")
+	}
+	if r, _ := s.Get("e"); !s.Failed("e") || s.Failed("b") || r.Reason != "duplicate" {
+		t.Errorf("expected only e to have failed; got %+v", r)
+	}
+	if rs := s.Playlist("p"); len(rs) != 2 || rs[0].EpisodeID != "a" || rs[1].EpisodeID != "b" {
+		t.Errorf("expected records a and b in playlist p; got %+v", rs)
+	}
+
+	if err := s.Reset("p", []Record{{EpisodeID: "d", VideoID: "vd", Status: StatusPublished}}); err != nil {
+		t.Fatalf("could not reset playlist: %v", err)
+	}
+	s, err = Open(path)
+	if err != nil {
+		t.Fatalf("could not reopen ledger: %v", err)
+	}
+	if _, ok := s.Get("a"); ok {
+		t.Errorf("record a should have been removed by reset")
+	}
+	if r, ok := s.Get("d"); !ok || r.Playlist != "p" {
+		t.Errorf("expected record d in playlist p; got %+v", r)
+	}
+	if _, ok := s.Get("c"); !ok {
+		t.Errorf("record c in another playlist should have been kept")
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package youtube
+
+import (
+	"fmt"
+	"os"
+
+	youtube "google.golang.org/api/youtube/v3"
+)
+
+// CaptionScope is the OAuth2 scope needed to upload captions.
+const CaptionScope = youtube.YoutubeForceSslScope
+
+// UploadCaption adds the caption file in the given path to a video, as a
+// track with the given language, such as "en-US", and name. An empty name
+// identifies the main track for the language. The token of the client must
+// grant CaptionScope; if it does not, the error wraps ErrMissingScope.
+func (c *Client) UploadCaption(video, language, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open %v: %v", path, err)
+	}
+	defer f.Close()
+
+	call := c.svc.Captions.Insert("snippet", &youtube.Caption{
+		Snippet: &youtube.CaptionSnippet{
+			VideoId:  video,
+			Language: language,
+			Name:     name,
+		},
+	})
+	if _, err := call.Media(f).Do(); err != nil {
+		return fmt.Errorf("could not upload %s captions: %w", language, scopeError(err))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package youtube
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/oauth2"
+	youtube "google.golang.org/api/youtube/v3"
+)
+
+func TestUploadCaptionMissingScope(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error": {"code": 403, "message": "Insufficient Permission",
+			"errors": [{"reason": "insufficientPermissions", "message": "Insufficient Permission"}]}}`))
+	}))
+	defer s.Close()
+
+	svc, err := youtube.New(s.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.BasePath = s.URL + "/"
+	c := &Client{svc: svc, log: t.Logf}
+
+	path := filepath.Join(t.TempDir(), "en.srt")
+	if err := os.WriteFile(path, []byte("1\n00:00:00,000 --> 00:00:01,000\nHi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UploadCaption("vid", "en", "", path); !errors.Is(err, ErrMissingScope) {
+		t.Errorf("expected ErrMissingScope; got %v", err)
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("access_token"); got != "secret" {
+			t.Errorf("expected access token secret; got %q", got)
+		}
+		w.Write([]byte(`{"scope": "https://www.googleapis.com/auth/youtube https://www.googleapis.com/auth/youtube.upload"}`))
+	}))
+	defer s.Close()
+	defer func(u string) { tokenInfoURL = u }(tokenInfoURL)
+	tokenInfoURL = s.URL
+
+	c := &Client{tokens: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "secret"})}
+	for scope, want := range map[string]bool{
+		youtube.YoutubeUploadScope: true,
+		CaptionScope:               false,
+	} {
+		got, err := c.HasScope(context.Background(), scope)
+		if err != nil {
+			t.Fatalf("could not check scope: %v", err)
+		}
+		if got != want {
+			t.Errorf("HasScope(%q) = %v; want %v", scope, got, want)
+		}
+	}
+}
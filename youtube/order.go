@@ -0,0 +1,164 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	youtube "google.golang.org/api/youtube/v3"
+)
+
+// A Move sets the position of a playlist item.
+type Move struct {
+	ItemID   string
+	Position int64
+}
+
+// PlanMoves returns the fewest moves that sort the playlist items with the
+// given IDs, in playlist order, so the ones in target come first and in that
+// order. The items not in target keep their relative order after them.
+// Moves must be applied in the returned order.
+func PlanMoves(current, target []string) []Move {
+	rank := make(map[string]int)
+	for i, id := range target {
+		if _, ok := rank[id]; !ok {
+			rank[id] = i
+		}
+	}
+
+	// We work with the final position of every item, its rank.
+	ranks := make([]int, len(current))
+	ids := make(map[int]string)
+	for i, id := range current {
+		r, ok := rank[id]
+		if !ok {
+			r = len(target) + i
+		}
+		ranks[i], ids[r] = r, id
+	}
+	sorted := append([]int(nil), ranks...)
+	sort.Ints(sorted)
+
+	// The items in a longest increasing subsequence of ranks stay where they
+	// are, and every other item is moved right after the item preceding it
+	// in rank order. Moving them by increasing rank ensures that item is in
+	// its final place already.
+	var moving []int
+	for i, keep := range longestIncreasing(ranks) {
+		if !keep {
+			moving = append(moving, ranks[i])
+		}
+	}
+	sort.Ints(moving)
+
+	list := append([]int(nil), ranks...)
+	var moves []Move
+	for _, r := range moving {
+		list = remove(list, r)
+		pos := 0
+		if i := sort.SearchInts(sorted, r); i > 0 {
+			pos = index(list, sorted[i-1]) + 1
+		}
+		list = append(list[:pos], append([]int{r}, list[pos:]...)...)
+		moves = append(moves, Move{ItemID: ids[r], Position: int64(pos)})
+	}
+	return moves
+}
+
+// remove returns list without the first occurrence of v.
+func remove(list []int, v int) []int {
+	i := index(list, v)
+	return append(list[:i], list[i+1:]...)
+}
+
+// index returns the index of v in list, or -1 if it is not there.
+func index(list []int, v int) int {
+	for i, w := range list {
+		if w == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// longestIncreasing reports, for each element of seq, whether it belongs to
+// one of the longest strictly increasing subsequences of seq. The elements
+// outside of it are the fewest that need to move to sort seq.
+func longestIncreasing(seq []int) []bool {
+	// tails[k] is the index of the smallest element ending an increasing
+	// subsequence of length k+1, and prev the element before each one.
+	var tails []int
+	prev := make([]int, len(seq))
+	for i, v := range seq {
+		k := sort.Search(len(tails), func(k int) bool { return seq[tails[k]] >= v })
+		prev[i] = -1
+		if k > 0 {
+			prev[i] = tails[k-1]
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+
+	in := make([]bool, len(seq))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			in[i] = true
+		}
+	}
+	return in
+}
+
+// Reorder moves the items of the given playlist, with the fewest position
+// changes, so the ones with the given IDs come first and in that order. It
+// returns the number of items moved.
+func (c *Client) Reorder(ctx context.Context, playlist string, target []string) (int, error) {
+	items, err := c.PlaylistItems(ctx, playlist)
+	if err != nil {
+		return 0, err
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Position < items[j].Position })
+
+	current := make([]string, len(items))
+	videos := make(map[string]string)
+	for i, item := range items {
+		current[i] = item.ID
+		videos[item.ID] = item.VideoID
+	}
+
+	moves := PlanMoves(current, target)
+	for i, m := range moves {
+		c.log("moving video %s to position %d", videos[m.ItemID], m.Position)
+		call := c.svc.PlaylistItems.Update("snippet", &youtube.PlaylistItem{
+			Id: m.ItemID,
+			Snippet: &youtube.PlaylistItemSnippet{
+				PlaylistId: playlist,
+				Position:   m.Position,
+				ResourceId: &youtube.ResourceId{
+					VideoId: videos[m.ItemID],
+					Kind:    "youtube#video",
+				},
+				ForceSendFields: []string{"Position"},
+			},
+		})
+		if _, err := call.Context(ctx).Do(); err != nil {
+			return i, fmt.Errorf("could not move video %s: %v", videos[m.ItemID], err)
+		}
+	}
+	return len(moves), nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package youtube
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlanMoves(t *testing.T) {
+	tests := []struct {
+		current, target string
+		want            string // The playlist after the moves.
+		moves           int
+	}{
+		{"", "", "", 0},
+		{"abc", "abc", "abc", 0},
+		{"acbd", "abcd", "abcd", 1},
+		{"dabc", "abcd", "abcd", 1},
+		{"dcba", "abcd", "abcd", 3},
+		{"xaybz", "ab", "abxyz", 2},
+		{"bxa", "ab", "abx", 1},
+		{"ebcda", "abcde", "abcde", 2},
+	}
+	for _, tt := range tests {
+		current, target := strings.Split(tt.current, ""), strings.Split(tt.target, "")
+		moves := PlanMoves(current, target)
+
+		// We apply the moves as YouTube does.
+		list := append([]string(nil), current...)
+		for _, m := range moves {
+			for i, id := range list {
+				if id == m.ItemID {
+					list = append(list[:i], list[i+1:]...)
+					break
+				}
+			}
+			list = append(list[:m.Position], append([]string{m.ItemID}, list[m.Position:]...)...)
+		}
+		if got := strings.Join(list, ""); got != tt.want {
+			t.Errorf("moving %s to %s gave %s with %v; expected %s", tt.current, tt.target, got, moves, tt.want)
+		}
+		if len(moves) != tt.moves {
+			t.Errorf("moving %s to %s took %d moves; expected %d", tt.current, tt.target, len(moves), tt.moves)
+		}
+	}
+}
+
+func TestLongestIncreasing(t *testing.T) {
+	tests := []struct {
+		seq  []int
+		want string
+	}{
+		{nil, ""},
+		{[]int{1, 2, 3}, "111"},
+		{[]int{3, 2, 1}, "001"},
+		{[]int{1, 5, 2, 3, 4}, "10111"},
+		{[]int{4, 1, 2, 3, 0}, "01110"},
+	}
+	for _, tt := range tests {
+		got := ""
+		for _, in := range longestIncreasing(tt.seq) {
+			if in {
+				got += "1"
+			} else {
+				got += "0"
+			}
+		}
+		if got != tt.want {
+			t.Errorf("longestIncreasing(%v) = %s; expected %s", tt.seq, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	youtube "google.golang.org/api/youtube/v3"
+)
+
+// Defaults for the polling of WaitUntilProcessed.
+const (
+	DefaultPollInterval      = 10 * time.Second
+	DefaultMaxPollInterval   = 2 * time.Minute
+	DefaultProcessingTimeout = 30 * time.Minute
+)
+
+// ErrStillProcessing is returned by WaitUntilProcessed when a video is not
+// processed before the deadline. The video may still be processed later.
+var ErrStillProcessing = errors.New("video is still being processed")
+
+// A ProcessingError is returned by WaitUntilProcessed when YouTube fails to
+// process, rejects, or deletes a video, which will never be processed.
+type ProcessingError struct {
+	Video  string
+	Status string // Such as "failed", "rejected", or "deleted".
+	Reason string // Reason given by YouTube, such as "duplicate"; may be empty.
+}
+
+func (e *ProcessingError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("video %s was %s", e.Video, e.Status)
+	}
+	return fmt.Sprintf("video %s was %s: %s", e.Video, e.Status, e.Reason)
+}
+
+// WaitUntilProcessed blocks until the video is processed. It checks the status
+// of the video after c.PollInterval, waiting twice as long after every check,
+// until c.ProcessingTimeout passes or ctx is done, and then returns an error
+// wrapping ErrStillProcessing. If the video can not be processed, the error is
+// a *ProcessingError.
+func (c *Client) WaitUntilProcessed(ctx context.Context, video string) error {
+	wait, max, timeout := c.PollInterval, c.MaxPollInterval, c.ProcessingTimeout
+	if wait <= 0 {
+		wait = DefaultPollInterval
+	}
+	if max <= 0 {
+		max = DefaultMaxPollInterval
+	}
+	if max < wait {
+		max = wait
+	}
+	if timeout <= 0 {
+		timeout = DefaultProcessingTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for retries := 0; ; {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", ErrStillProcessing, ctx.Err())
+		}
+		if wait *= 2; wait > max {
+			wait = max
+		}
+
+		done, err := c.processed(ctx, video)
+		if _, ok := err.(*ProcessingError); ok {
+			return err
+		}
+		if err != nil {
+			// Failing to check the status says nothing about the video.
+			if retries++; retries > maxRetries {
+				return fmt.Errorf("could not check status of video %s: %v", video, err)
+			}
+			c.log("could not check status of video %s: %v", video, err)
+			continue
+		}
+		if done {
+			return nil
+		}
+		retries = 0
+	}
+}
+
+// processed reports whether a video is processed, or returns a
+// *ProcessingError if it will never be.
+func (c *Client) processed(ctx context.Context, video string) (bool, error) {
+	res, err := c.svc.Videos.List("status,processingDetails").Id(video).Context(ctx).Do()
+	if err != nil {
+		return false, err
+	}
+	if len(res.Items) == 0 {
+		return false, &ProcessingError{Video: video, Status: "deleted"}
+	}
+	return processingStatus(video, res.Items[0])
+}
+
+// processingStatus reports whether the given video is processed, given its
+// status and processing details.
+func processingStatus(id string, v *youtube.Video) (bool, error) {
+	var upload, processing string
+	if s := v.Status; s != nil {
+		upload = s.UploadStatus
+		switch upload {
+		case "failed":
+			return false, &ProcessingError{Video: id, Status: upload, Reason: s.FailureReason}
+		case "rejected":
+			return false, &ProcessingError{Video: id, Status: upload, Reason: s.RejectionReason}
+		case "deleted":
+			return false, &ProcessingError{Video: id, Status: upload}
+		}
+	}
+	if d := v.ProcessingDetails; d != nil {
+		processing = d.ProcessingStatus
+		switch processing {
+		case "failed":
+			return false, &ProcessingError{Video: id, Status: processing, Reason: d.ProcessingFailureReason}
+		case "terminated":
+			return false, &ProcessingError{Video: id, Status: processing}
+		}
+	}
+	return upload == "processed" || processing == "succeeded", nil
+}
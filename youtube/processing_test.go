@@ -0,0 +1,96 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package youtube
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	youtube "google.golang.org/api/youtube/v3"
+)
+
+// videoServer is a fake videos endpoint returning the given responses in
+// order, repeating the last one.
+func videoServer(t *testing.T, responses ...string) (*Client, *int) {
+	calls := new(int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("part"); got != "status,processingDetails" {
+			t.Errorf("expected parts status,processingDetails; got %q", got)
+		}
+		res := responses[len(responses)-1]
+		if *calls < len(responses) {
+			res = responses[*calls]
+		}
+		*calls++
+		if res == "" {
+			http.Error(w, "backend error", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(res))
+	}))
+	t.Cleanup(srv.Close)
+
+	svc, err := youtube.New(srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.BasePath = srv.URL + "/"
+	c := &Client{svc: svc, log: t.Logf, PollInterval: time.Millisecond, MaxPollInterval: 4 * time.Millisecond}
+	return c, calls
+}
+
+func TestWaitUntilProcessed(t *testing.T) {
+	c, calls := videoServer(t,
+		`{"items": [{"status": {"uploadStatus": "uploaded"}, "processingDetails": {"processingStatus": "processing"}}]}`,
+		"",
+		`{"items": [{"status": {"uploadStatus": "processed"}, "processingDetails": {"processingStatus": "succeeded"}}]}`,
+	)
+	if err := c.WaitUntilProcessed(context.Background(), "vid"); err != nil {
+		t.Fatalf("could not wait: %v", err)
+	}
+	if *calls != 3 {
+		t.Errorf("expected 3 checks; got %d", *calls)
+	}
+}
+
+func TestWaitUntilProcessedErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     *ProcessingError
+	}{
+		{"rejected", `{"items": [{"status": {"uploadStatus": "rejected", "rejectionReason": "duplicate"}}]}`, &ProcessingError{"vid", "rejected", "duplicate"}},
+		{"failed", `{"items": [{"status": {"uploadStatus": "failed", "failureReason": "codec"}}]}`, &ProcessingError{"vid", "failed", "codec"}},
+		{"processing failed", `{"items": [{"status": {"uploadStatus": "uploaded"}, "processingDetails": {"processingStatus": "failed", "processingFailureReason": "transcodeFailed"}}]}`, &ProcessingError{"vid", "failed", "transcodeFailed"}},
+		{"missing", `{"items": []}`, &ProcessingError{"vid", "deleted", ""}},
+	}
+	for _, tt := range tests {
+		c, _ := videoServer(t, tt.response)
+		err := c.WaitUntilProcessed(context.Background(), "vid")
+		var perr *ProcessingError
+		if !errors.As(err, &perr) || *perr != *tt.want {
+			t.Errorf("%s: expected error %v; got %v", tt.name, tt.want, err)
+		}
+	}
+
+	c, _ := videoServer(t, `{"items": [{"status": {"uploadStatus": "uploaded"}, "processingDetails": {"processingStatus": "processing"}}]}`)
+	c.ProcessingTimeout = 20 * time.Millisecond
+	if err := c.WaitUntilProcessed(context.Background(), "vid"); !errors.Is(err, ErrStillProcessing) {
+		t.Errorf("expected ErrStillProcessing; got %v", err)
+	}
+}
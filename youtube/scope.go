@@ -0,0 +1,83 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+)
+
+// tokenInfoURL is the endpoint describing OAuth2 access tokens.
+var tokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+
+// ErrMissingScope is returned when the OAuth2 token of a client does not grant
+// a permission needed by a request, usually because the token was authorized
+// before the permission was required. Delete the token and authorize again.
+var ErrMissingScope = errors.New("token does not grant the required permission")
+
+// HasScope reports whether the OAuth2 token of the client grants the given
+// scope, such as CaptionScope.
+func (c *Client) HasScope(ctx context.Context, scope string) (bool, error) {
+	if c.tokens == nil {
+		return false, errors.New("no token to check")
+	}
+	tok, err := c.tokens.Token()
+	if err != nil {
+		return false, fmt.Errorf("could not get token: %v", err)
+	}
+	req, err := http.NewRequest("GET", tokenInfoURL+"?access_token="+url.QueryEscape(tok.AccessToken), nil)
+	if err != nil {
+		return false, err
+	}
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return false, fmt.Errorf("could not get token info: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("could not get token info: %s", responseError(res))
+	}
+	var info struct {
+		Scope string `json:"scope"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&info); err != nil {
+		return false, fmt.Errorf("could not decode token info: %v", err)
+	}
+	for _, s := range strings.Fields(info.Scope) {
+		if s == scope {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// scopeError returns an error wrapping ErrMissingScope if err is caused by
+// the token lacking a permission, or err otherwise.
+func scopeError(err error) error {
+	if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusForbidden {
+		for _, e := range gerr.Errors {
+			if e.Reason == "insufficientPermissions" {
+				return fmt.Errorf("%w: %v", ErrMissingScope, err)
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,236 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package youtube
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	youtube "google.golang.org/api/youtube/v3"
+)
+
+// DefaultChunkSize is the chunk size used when Client.ChunkSize is zero.
+const DefaultChunkSize = 8 << 20
+
+// chunkAlign is the size every chunk but the last must be a multiple of.
+const chunkAlign = 256 << 10
+
+// maxRetries is the number of consecutive failed requests after which an
+// upload is abandoned. It can still be resumed later.
+const maxRetries = 5
+
+// uploadURL is the endpoint where resumable upload sessions are created.
+var uploadURL = "https://www.googleapis.com/upload/youtube/v3/videos"
+
+// sleep is time.Sleep, replaced in tests.
+var sleep = time.Sleep
+
+// errSessionExpired is returned when an upload session is no longer valid.
+var errSessionExpired = errors.New("upload session expired")
+
+// Upload uploads the video in the given path to YouTube with the given details.
+//
+// The video is sent in chunks of c.ChunkSize bytes using a resumable upload
+// session, whose URI is stored next to the video, in a file with the same
+// name and an .upload extension, until the upload completes. If that file
+// exists, the upload resumes where it was left, even by another process.
+func (c *Client) Upload(v Video, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("could not open %v: %v", path, err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("could not stat %v: %v", path, err)
+	}
+	size := fi.Size()
+
+	session := path + ".upload"
+	var uri string
+	if data, err := ioutil.ReadFile(session); err == nil {
+		uri = strings.TrimSpace(string(data))
+		c.log("resuming upload of %s", path)
+	}
+
+	for {
+		if uri == "" {
+			if uri, err = c.startUpload(v, size); err != nil {
+				return "", err
+			}
+			if err := ioutil.WriteFile(session, []byte(uri), 0644); err != nil {
+				return "", fmt.Errorf("could not save upload session: %v", err)
+			}
+		}
+
+		id, err := c.resumeUpload(uri, f, size)
+		if err == errSessionExpired {
+			c.log("upload session expired; starting over")
+			uri = ""
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := os.Remove(session); err != nil {
+			c.log("could not remove %s: %v", session, err)
+		}
+		return id, nil
+	}
+}
+
+// startUpload creates a resumable upload session for a video with the given
+// metadata and size, and returns its URI.
+func (c *Client) startUpload(v Video, size int64) (string, error) {
+	body, err := v.body()
+	if err != nil {
+		return "", fmt.Errorf("could not encode video metadata: %v", err)
+	}
+	req, err := http.NewRequest("POST", uploadURL+"?uploadType=resumable&part="+videoParts, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+	req.Header.Set("X-Upload-Content-Type", "video/*")
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("could not start upload: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("could not start upload: %s", responseError(res))
+	}
+	uri := res.Header.Get("Location")
+	if uri == "" {
+		return "", errors.New("could not start upload: no session URI in response")
+	}
+	return uri, nil
+}
+
+// resumeUpload sends the contents of r, of the given size, to the upload
+// session at uri, starting where the session was left, and returns the ID of
+// the uploaded video.
+func (c *Client) resumeUpload(uri string, r io.ReaderAt, size int64) (string, error) {
+	chunk := c.ChunkSize
+	if chunk <= 0 {
+		chunk = DefaultChunkSize
+	}
+	chunk = (chunk + chunkAlign - 1) / chunkAlign * chunkAlign
+
+	// We first ask how much the session received, then send the rest. After
+	// a failed request, we ask again, waiting longer every time.
+	query := true
+	var offset int64
+	for retries := 0; ; {
+		var (
+			res *http.Response
+			err error
+		)
+		if query {
+			res, err = c.put(uri, nil, fmt.Sprintf("bytes */%d", size))
+		} else {
+			end := offset + chunk
+			if end > size {
+				end = size
+			}
+			body := io.NewSectionReader(r, offset, end-offset)
+			res, err = c.put(uri, body, fmt.Sprintf("bytes %d-%d/%d", offset, end-1, size))
+		}
+
+		switch {
+		case err == nil && (res.StatusCode == http.StatusOK || res.StatusCode == http.StatusCreated):
+			defer res.Body.Close()
+			var v youtube.Video
+			if err := json.NewDecoder(res.Body).Decode(&v); err != nil {
+				return "", fmt.Errorf("could not decode uploaded video: %v", err)
+			}
+			if c.Progress != nil {
+				c.Progress(size, size)
+			}
+			return v.Id, nil
+		case err == nil && res.StatusCode == http.StatusPermanentRedirect:
+			res.Body.Close()
+			offset = rangeEnd(res.Header.Get("Range"))
+			if c.Progress != nil && !query {
+				c.Progress(offset, size)
+			}
+			query, retries = false, 0
+			continue
+		case err == nil && (res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone):
+			res.Body.Close()
+			return "", errSessionExpired
+		case err == nil && res.StatusCode < 500:
+			defer res.Body.Close()
+			return "", fmt.Errorf("could not upload video: %s", responseError(res))
+		case err == nil:
+			err = errors.New(responseError(res))
+			res.Body.Close()
+		}
+
+		retries++
+		if retries > maxRetries {
+			return "", fmt.Errorf("could not upload video: %v", err)
+		}
+		wait := time.Duration(1<<uint(retries)) * time.Second
+		c.log("upload failed: %v; retrying in %v", err, wait)
+		sleep(wait)
+		query = true
+	}
+}
+
+// put sends a PUT request with the given body and Content-Range header.
+func (c *Client) put(uri string, body io.Reader, contentRange string) (*http.Response, error) {
+	req, err := http.NewRequest("PUT", uri, body)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		req.ContentLength = 0
+	} else if s, ok := body.(*io.SectionReader); ok {
+		req.ContentLength = s.Size()
+	}
+	req.Header.Set("Content-Range", contentRange)
+	return c.http.Do(req)
+}
+
+// rangeEnd returns the number of bytes received by an upload session, given
+// the Range header of its response, like "bytes=0-1023".
+func rangeEnd(h string) int64 {
+	i := strings.LastIndex(h, "-")
+	if i < 0 {
+		return 0
+	}
+	n, err := strconv.ParseInt(h[i+1:], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n + 1
+}
+
+// responseError describes an unexpected HTTP response.
+func responseError(res *http.Response) string {
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1<<10))
+	return fmt.Sprintf("%s: %s", res.Status, bytes.TrimSpace(body))
+}
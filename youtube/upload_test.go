@@ -0,0 +1,122 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package youtube
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// uploadServer is a fake resumable upload endpoint.
+type uploadServer struct {
+	t        *testing.T
+	received []byte
+	failures int // Number of chunk requests to fail.
+	sessions int // Number of sessions started.
+}
+
+func (s *uploadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		s.sessions++
+		s.received = nil
+		w.Header().Set("Location", "http://"+r.Host+"/session")
+		return
+	}
+
+	var size int
+	cr := r.Header.Get("Content-Range")
+	if i := strings.LastIndex(cr, "/"); i >= 0 {
+		size, _ = strconv.Atoi(cr[i+1:])
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	if len(body) > 0 {
+		if s.failures > 0 {
+			s.failures--
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		want := fmt.Sprintf("bytes %d-%d/%d", len(s.received), len(s.received)+len(body)-1, size)
+		if cr != want {
+			s.t.Errorf("expected Content-Range %q; got %q", want, cr)
+		}
+		s.received = append(s.received, body...)
+	}
+	if len(s.received) == size {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": "video"}`)
+		return
+	}
+	if len(s.received) > 0 {
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(s.received)-1))
+	}
+	w.WriteHeader(http.StatusPermanentRedirect)
+}
+
+func TestUpload(t *testing.T) {
+	sleep = func(time.Duration) {}
+	defer func() { sleep = time.Sleep }()
+
+	fake := &uploadServer{t: t, failures: 1}
+	s := httptest.NewServer(fake)
+	defer s.Close()
+	uploadURL = s.URL + "/upload"
+
+	data := bytes.Repeat([]byte("0123456789"), chunkAlign/4)
+	path := filepath.Join(t.TempDir(), "vid.mp4")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A previous run already sent the first chunk.
+	fake.sessions, fake.received = 1, data[:chunkAlign]
+	if err := ioutil.WriteFile(path+".upload", []byte(s.URL+"/session"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var progress []int64
+	c := &Client{
+		ChunkSize: 1,
+		Progress:  func(sent, total int64) { progress = append(progress, sent) },
+		http:      s.Client(),
+		log:       t.Logf,
+	}
+	id, err := c.Upload(Video{Title: "title", Description: "desc"}, path)
+	if err != nil {
+		t.Fatalf("could not upload: %v", err)
+	}
+	if id != "video" {
+		t.Errorf("expected video ID %q; got %q", "video", id)
+	}
+	if !bytes.Equal(fake.received, data) {
+		t.Errorf("uploaded %d bytes different from the %d in the file", len(fake.received), len(data))
+	}
+	if fake.sessions != 1 {
+		t.Errorf("expected the upload to be resumed; %d sessions were started", fake.sessions)
+	}
+	if want := fmt.Sprint([]int64{2 * chunkAlign, int64(len(data))}); fmt.Sprint(progress) != want {
+		t.Errorf("expected progress %s; got %v", want, progress)
+	}
+	if _, err := os.Stat(path + ".upload"); !os.IsNotExist(err) {
+		t.Errorf("expected upload session file to be removed; got %v", err)
+	}
+}
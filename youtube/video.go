@@ -0,0 +1,175 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package youtube
+
+import (
+	"encoding/json"
+	"time"
+
+	youtube "google.golang.org/api/youtube/v3"
+)
+
+// Privacy statuses of a video.
+const (
+	Public   = "public"
+	Unlisted = "unlisted"
+	Private  = "private"
+)
+
+// Licenses of a video.
+const (
+	LicenseYouTube        = "youtube"
+	LicenseCreativeCommon = "creativeCommon"
+)
+
+// videoParts are the parts of a video sent on upload.
+const videoParts = "snippet,status,recordingDetails"
+
+// A Video contains the details of a video to upload.
+type Video struct {
+	Title       string
+	Description string
+	Tags        []string
+	Privacy     string    // Public, Unlisted, or Private; Public if empty.
+	PublishAt   time.Time // If not zero, the video is private until then, and public after.
+	Metadata
+}
+
+// Metadata contains the optional details of a video. The zero values leave
+// the choice to YouTube, which uses the defaults of the channel.
+type Metadata struct {
+	CategoryID           string    `json:"categoryId,omitempty"`           // As listed by the videoCategories API, like "28" for Science & Technology.
+	DefaultLanguage      string    `json:"defaultLanguage,omitempty"`      // Language of the title and description, like "en".
+	DefaultAudioLanguage string    `json:"defaultAudioLanguage,omitempty"` // Language spoken in the video, like "en-US".
+	License              string    `json:"license,omitempty"`              // LicenseYouTube or LicenseCreativeCommon.
+	Embeddable           *bool     `json:"embeddable,omitempty"`
+	PublicStatsViewable  *bool     `json:"publicStatsViewable,omitempty"`
+	MadeForKids          *bool     `json:"selfDeclaredMadeForKids,omitempty"`
+	RecordingDate        time.Time `json:"recordingDate,omitempty"`
+	Location             *Location `json:"location,omitempty"`
+	LocationDescription  string    `json:"locationDescription,omitempty"`
+}
+
+// A Location is the place where a video was recorded.
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude,omitempty"`
+}
+
+// Override returns the metadata in m, replaced by the non zero values in o.
+func (m Metadata) Override(o Metadata) Metadata {
+	if o.CategoryID != "" {
+		m.CategoryID = o.CategoryID
+	}
+	if o.DefaultLanguage != "" {
+		m.DefaultLanguage = o.DefaultLanguage
+	}
+	if o.DefaultAudioLanguage != "" {
+		m.DefaultAudioLanguage = o.DefaultAudioLanguage
+	}
+	if o.License != "" {
+		m.License = o.License
+	}
+	if o.Embeddable != nil {
+		m.Embeddable = o.Embeddable
+	}
+	if o.PublicStatsViewable != nil {
+		m.PublicStatsViewable = o.PublicStatsViewable
+	}
+	if o.MadeForKids != nil {
+		m.MadeForKids = o.MadeForKids
+	}
+	if !o.RecordingDate.IsZero() {
+		m.RecordingDate = o.RecordingDate
+	}
+	if o.Location != nil {
+		m.Location = o.Location
+	}
+	if o.LocationDescription != "" {
+		m.LocationDescription = o.LocationDescription
+	}
+	return m
+}
+
+// resource returns the representation of the video in the YouTube API.
+func (v Video) resource() *youtube.Video {
+	status := &youtube.VideoStatus{PrivacyStatus: v.Privacy, License: v.License}
+	if status.PrivacyStatus == "" {
+		status.PrivacyStatus = Public
+	}
+	// YouTube only schedules private videos.
+	if !v.PublishAt.IsZero() {
+		status.PrivacyStatus = Private
+		status.PublishAt = v.PublishAt.UTC().Format(time.RFC3339)
+	}
+	// False values are only sent when explicitly set.
+	if v.Embeddable != nil {
+		status.Embeddable = *v.Embeddable
+		status.ForceSendFields = append(status.ForceSendFields, "Embeddable")
+	}
+	if v.PublicStatsViewable != nil {
+		status.PublicStatsViewable = *v.PublicStatsViewable
+		status.ForceSendFields = append(status.ForceSendFields, "PublicStatsViewable")
+	}
+
+	res := &youtube.Video{
+		Snippet: &youtube.VideoSnippet{
+			Title:                v.Title,
+			Description:          v.Description,
+			Tags:                 v.Tags,
+			CategoryId:           v.CategoryID,
+			DefaultLanguage:      v.DefaultLanguage,
+			DefaultAudioLanguage: v.DefaultAudioLanguage,
+		},
+		Status: status,
+	}
+	if !v.RecordingDate.IsZero() || v.Location != nil || v.LocationDescription != "" {
+		res.RecordingDetails = &youtube.VideoRecordingDetails{LocationDescription: v.LocationDescription}
+		if !v.RecordingDate.IsZero() {
+			res.RecordingDetails.RecordingDate = v.RecordingDate.UTC().Format(time.RFC3339)
+		}
+		if l := v.Location; l != nil {
+			res.RecordingDetails.Location = &youtube.GeoPoint{
+				Latitude:        l.Latitude,
+				Longitude:       l.Longitude,
+				Altitude:        l.Altitude,
+				ForceSendFields: []string{"Latitude", "Longitude"},
+			}
+		}
+	}
+	return res
+}
+
+// body returns the JSON encoded representation of the video in the YouTube
+// API. The version of the API client we use has no field for the status
+// selfDeclaredMadeForKids, so it is added to the encoded status.
+func (v Video) body() ([]byte, error) {
+	data, err := json.Marshal(v.resource())
+	if err != nil || v.MadeForKids == nil {
+		return data, err
+	}
+
+	var res map[string]interface{}
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	status, _ := res["status"].(map[string]interface{})
+	if status == nil {
+		status = make(map[string]interface{})
+		res["status"] = status
+	}
+	status["selfDeclaredMadeForKids"] = *v.MadeForKids
+	return json.Marshal(res)
+}
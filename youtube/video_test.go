@@ -0,0 +1,84 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to writing, software distributed
+// under the License is distributed on a "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.
+//
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package youtube
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVideoResource(t *testing.T) {
+	madrid := time.FixedZone("CET", 3600)
+	tests := []struct {
+		v                  Video
+		privacy, publishAt string
+	}{
+		{Video{}, "public", ""},
+		{Video{Privacy: Unlisted}, "unlisted", ""},
+		{Video{Privacy: Public, PublishAt: time.Date(2030, 1, 1, 10, 0, 0, 0, madrid)}, "private", "2030-01-01T09:00:00Z"},
+	}
+	for _, tt := range tests {
+		s := tt.v.resource().Status
+		if s.PrivacyStatus != tt.privacy || s.PublishAt != tt.publishAt {
+			t.Errorf("expected status %s at %q for %+v; got %s at %q", tt.privacy, tt.publishAt, tt.v, s.PrivacyStatus, s.PublishAt)
+		}
+	}
+}
+
+func TestVideoBody(t *testing.T) {
+	no, yes := false, true
+	v := Video{
+		Title: "title",
+		Metadata: Metadata{CategoryID: "28", DefaultAudioLanguage: "en"}.Override(Metadata{
+			DefaultAudioLanguage: "es",
+			License:              LicenseCreativeCommon,
+			Embeddable:           &no,
+			PublicStatsViewable:  &yes,
+			MadeForKids:          &no,
+			RecordingDate:        time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			Location:             &Location{Latitude: 40.4, Longitude: 0},
+		}),
+	}
+	data, err := v.body()
+	if err != nil {
+		t.Fatalf("could not encode video: %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("could not decode video: %v", err)
+	}
+	want := map[string]interface{}{
+		"snippet": map[string]interface{}{
+			"title":                "title",
+			"categoryId":           "28",
+			"defaultAudioLanguage": "es",
+		},
+		"status": map[string]interface{}{
+			"privacyStatus":           "public",
+			"license":                 "creativeCommon",
+			"embeddable":              false,
+			"publicStatsViewable":     true,
+			"selfDeclaredMadeForKids": false,
+		},
+		"recordingDetails": map[string]interface{}{
+			"recordingDate": "2024-01-02T00:00:00Z",
+			"location":      map[string]interface{}{"latitude": 40.4, "longitude": 0.0},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected video\n%v\ngot\n%v", want, got)
+	}
+}
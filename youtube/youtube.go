@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"time"
 
@@ -16,8 +17,26 @@ import (
 
 // Client provides methods to access the YouTube API.
 type Client struct {
-	svc *youtube.Service
-	log func(string, ...interface{})
+	// ChunkSize is the number of bytes sent in every request while uploading
+	// a video, rounded up to a multiple of 256 KiB. If zero, DefaultChunkSize
+	// is used.
+	ChunkSize int64
+	// Progress, if not nil, is called after every chunk of a video is
+	// uploaded, with the number of bytes uploaded so far and the total.
+	Progress func(sent, total int64)
+	// PollInterval is the time WaitUntilProcessed waits before checking the
+	// status of a video for the first time. It doubles after every check, up
+	// to MaxPollInterval. If zero, DefaultPollInterval and
+	// DefaultMaxPollInterval are used respectively.
+	PollInterval, MaxPollInterval time.Duration
+	// ProcessingTimeout is the longest WaitUntilProcessed waits for a video
+	// to be processed. If zero, DefaultProcessingTimeout is used.
+	ProcessingTimeout time.Duration
+
+	svc    *youtube.Service
+	http   *http.Client
+	tokens oauth2.TokenSource
+	log    func(string, ...interface{})
 }
 
 // NewClient creates a new authenticated client given the path of an oauth2 secret service and a token.
@@ -34,54 +53,98 @@ func NewClient(secret, token string, log func(string, ...interface{})) (*Client,
 	if err != nil {
 		return nil, fmt.Errorf("could not open %s: %v", secret, err)
 	}
-	cfg, err := google.ConfigFromJSON(data, youtube.YoutubeScope, youtube.YoutubeReadonlyScope, youtube.YoutubeUploadScope)
+	cfg, err := google.ConfigFromJSON(data, youtube.YoutubeScope, youtube.YoutubeReadonlyScope, youtube.YoutubeUploadScope, CaptionScope)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse config: %v", err)
 	}
-	svc, err := youtube.New(cfg.Client(context.Background(), &tok))
+	tokens := cfg.TokenSource(context.Background(), &tok)
+	c, err := NewClientFromHTTP(oauth2.NewClient(context.Background(), tokens), log)
+	if err != nil {
+		return nil, err
+	}
+	c.tokens = tokens
+	return c, nil
+}
+
+// NewClientFromHTTP creates a new client sending its requests with the given
+// HTTP client, which must authenticate them. HasScope can not be used on the
+// returned client.
+func NewClientFromHTTP(client *http.Client, log func(string, ...interface{})) (*Client, error) {
+	svc, err := youtube.New(client)
 	if err != nil {
 		return nil, fmt.Errorf("could not create youtube client: %v", err)
 	}
 	if log == nil {
 		log = func(string, ...interface{}) {}
 	}
-	return &Client{svc, log}, nil
+	return &Client{svc: svc, http: client, log: log}, nil
 }
 
-// FetchLastPublished finds the number of the latest episode published in the playlist.
-func (c *Client) FetchLastPublished(playlist string) (*youtube.PlaylistItem, error) {
-	res, err := c.svc.PlaylistItems.List("snippet").PlaylistId(playlist).MaxResults(1).Do()
+// A PlaylistItem is a video in a playlist.
+type PlaylistItem struct {
+	ID               string // ID of the playlist item, not of the video.
+	VideoID          string
+	Title            string
+	Description      string
+	Position         int64     // Position in the playlist, starting at 0.
+	PublishedAt      time.Time // When the video was added to the playlist.
+	VideoPublishedAt time.Time // When the video was published; zero if it is not public.
+	Privacy          string    // Privacy status of the item: public, unlisted, or private.
+}
+
+// PlaylistItems returns every item in the given playlist, in playlist order,
+// fetching as many pages as needed.
+func (c *Client) PlaylistItems(ctx context.Context, playlist string) ([]PlaylistItem, error) {
+	var items []PlaylistItem
+	call := c.svc.PlaylistItems.List("snippet,contentDetails,status").PlaylistId(playlist).MaxResults(50)
+	err := call.Pages(ctx, func(res *youtube.PlaylistItemListResponse) error {
+		for _, i := range res.Items {
+			items = append(items, playlistItem(i))
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not fetch playlist: %v", err)
 	}
+	return items, nil
+}
 
-	if len(res.Items) == 0 {
-		return nil, fmt.Errorf("playlist is empty")
+// playlistItem converts an item returned by the YouTube API into a PlaylistItem.
+func playlistItem(i *youtube.PlaylistItem) PlaylistItem {
+	item := PlaylistItem{ID: i.Id}
+	if s := i.Snippet; s != nil {
+		item.Title = s.Title
+		item.Description = s.Description
+		item.Position = s.Position
+		item.PublishedAt, _ = time.Parse(time.RFC3339, s.PublishedAt)
+		if s.ResourceId != nil {
+			item.VideoID = s.ResourceId.VideoId
+		}
 	}
-	return res.Items[0], nil
+	if d := i.ContentDetails; d != nil {
+		if d.VideoId != "" {
+			item.VideoID = d.VideoId
+		}
+		item.VideoPublishedAt, _ = time.Parse(time.RFC3339, d.VideoPublishedAt)
+	}
+	if s := i.Status; s != nil {
+		item.Privacy = s.PrivacyStatus
+	}
+	return item
 }
 
-// Upload uploads the video in the given path to YouTube with the given details.
-func (c *Client) Upload(title, desc string, tags []string, path string) (string, error) {
+// SetThumbnail sets the image in the given path as the thumbnail of a video.
+func (c *Client) SetThumbnail(video, path string) error {
 	f, err := os.Open(path)
 	if err != nil {
-		return "", fmt.Errorf("could not open %v: %v", path, err)
+		return fmt.Errorf("could not open %v: %v", path, err)
 	}
 	defer f.Close()
 
-	v := &youtube.Video{
-		Snippet: &youtube.VideoSnippet{
-			Title:       title,
-			Description: desc,
-			Tags:        tags,
-		},
-		Status: &youtube.VideoStatus{PrivacyStatus: "public"},
-	}
-	v, err = c.svc.Videos.Insert("snippet,status", v).Media(f).Do()
-	if err != nil {
-		return "", fmt.Errorf("could not insert video: %v", err)
+	if _, err := c.svc.Thumbnails.Set(video).Media(f).Do(); err != nil {
+		return fmt.Errorf("could not set thumbnail: %v", err)
 	}
-	return v.Id, nil
+	return nil
 }
 
 // AddToPlaylist adds the given video id to a plyalist.
@@ -110,25 +173,3 @@ func (c *Client) Status(video string) (string, error) {
 	}
 	return res.Items[0].Status.UploadStatus, nil
 }
-
-// WaitUntilProcessed blocks until the video is processed or the given context is canceled.
-func (c *Client) WaitUntilProcessed(ctx context.Context, video string) error {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			s, err := c.Status(video)
-			if err != nil {
-				return fmt.Errorf("could not check status")
-			}
-			c.log("status of video is %q", s)
-			if s == "processed" {
-				return nil
-			}
-		case <-ctx.Done():
-			return ctx.Err()
-		}
-	}
-}